@@ -0,0 +1,178 @@
+package remover
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ASNInfo struct {
+	IP           string `yaml:"ip"`
+	ASN          int    `yaml:"asn"`
+	Organization string `yaml:"organization,omitempty"`
+	Country      string `yaml:"country,omitempty"`
+}
+
+type asnRange struct {
+	start        netip.Addr
+	end          netip.Addr
+	asn          int
+	organization string
+	country      string
+}
+
+type ASNDatabase struct {
+	ranges []asnRange
+}
+
+/*
+Loads an offline ASN database from a CSV/TSV file. The following formats are supported:
+  - MaxMind GeoLite2 ASN CSV: network,autonomous_system_number,autonomous_system_organization
+  - IP2Location ASN CSV: ip_from,ip_to,cidr,asn,as (IPs as integers)
+  - iptoasn.com TSV: range_start,range_end,AS_number,country_code,AS_description
+
+Rows which can't be parsed (e.g. headers) are skipped.
+*/
+func LoadASNDatabase(filename string) (*ASNDatabase, error) {
+	data, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if strings.Contains(filename, ".tsv") {
+		reader.Comma = '\t'
+	}
+
+	database := &ASNDatabase{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry, ok := parseASNRow(row); ok {
+			database.ranges = append(database.ranges, entry)
+		}
+	}
+	sort.Slice(database.ranges, func(i, j int) bool {
+		return database.ranges[i].start.Less(database.ranges[j].start)
+	})
+	log.Infof("Loaded %d ASN ranges from %s", len(database.ranges), filename)
+	return database, nil
+}
+
+func parseASNRow(row []string) (asnRange, bool) {
+	if len(row) < 3 {
+		return asnRange{}, false
+	}
+	var entry asnRange
+	if prefix, err := netip.ParsePrefix(row[0]); err == nil {
+		// MaxMind
+		entry.start, entry.end = getPrefixRange(prefix)
+		entry.asn = parseASN(row[1])
+		entry.organization = row[2]
+	} else {
+		start, ok := parseRangeAddress(row[0])
+		if !ok {
+			return asnRange{}, false
+		}
+		end, ok := parseRangeAddress(row[1])
+		if !ok {
+			return asnRange{}, false
+		}
+		entry.start, entry.end = start, end
+		if strings.Contains(row[2], "/") && len(row) >= 5 {
+			// IP2Location
+			entry.asn = parseASN(row[3])
+			entry.organization = row[4]
+		} else if len(row) >= 5 {
+			// iptoasn
+			entry.asn = parseASN(row[2])
+			entry.country = row[3]
+			entry.organization = row[4]
+		} else {
+			entry.asn = parseASN(row[2])
+		}
+	}
+	if entry.asn == 0 {
+		return asnRange{}, false
+	}
+	return entry, true
+}
+
+func parseASN(value string) int {
+	asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "AS"))
+	if err != nil {
+		return 0
+	}
+	return asn
+}
+
+// Range addresses are either provided as IP or as integer (IPv4 only).
+func parseRangeAddress(value string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	number, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	var bytes [4]byte
+	binary.BigEndian.PutUint32(bytes[:], uint32(number))
+	return netip.AddrFrom4(bytes), true
+}
+
+func getPrefixRange(prefix netip.Prefix) (netip.Addr, netip.Addr) {
+	start := prefix.Masked().Addr()
+	bytes := start.AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - uint(bit%8))
+	}
+	end, _ := netip.AddrFromSlice(bytes)
+	return start, end
+}
+
+// Returns the ASN information of the IP address, if it is contained in the database.
+func (database *ASNDatabase) Lookup(ipaddress string) (ASNInfo, bool) {
+	addr, err := netip.ParseAddr(ipaddress)
+	if err != nil {
+		return ASNInfo{}, false
+	}
+	addr = addr.Unmap()
+	// Find the last range starting before or at the address
+	index := sort.Search(len(database.ranges), func(i int) bool {
+		return addr.Less(database.ranges[i].start)
+	}) - 1
+	if index < 0 {
+		return ASNInfo{}, false
+	}
+	entry := database.ranges[index]
+	if entry.start.BitLen() != addr.BitLen() || entry.end.Less(addr) {
+		return ASNInfo{}, false
+	}
+	return ASNInfo{IP: ipaddress, ASN: entry.asn, Organization: entry.organization, Country: entry.country}, true
+}
+
+// Fills the ASN information and the WhoisInfo of the DNS records from the database.
+func (database *ASNDatabase) EnrichDNSRecords(records []DNSRecord) {
+	for i := range records {
+		var whois []string
+		for _, ipaddress := range append(append([]string{}, records[i].IPv4Addresses...), records[i].IPv6Addresses...) {
+			if info, ok := database.Lookup(ipaddress); ok {
+				records[i].ASNs = append(records[i].ASNs, info)
+				whois = AppendIfMissing(whois, "AS"+strconv.Itoa(info.ASN)+" "+info.Organization)
+			}
+		}
+		records[i].WhoisInfo = strings.Join(whois, ", ")
+	}
+}
@@ -0,0 +1,87 @@
+package remover
+
+import (
+	"testing"
+)
+
+func TestParseASNRow(t *testing.T) {
+	tests := []struct {
+		name  string
+		row   []string
+		ok    bool
+		start string
+		end   string
+		asn   int
+		org   string
+	}{
+		{"maxmind", []string{"1.0.0.0/24", "13335", "CLOUDFLARENET"}, true, "1.0.0.0", "1.0.0.255", 13335, "CLOUDFLARENET"},
+		{"maxmind ipv6", []string{"2001:db8::/32", "64500", "EXAMPLE"}, true, "2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", 64500, "EXAMPLE"},
+		{"maxmind header", []string{"network", "autonomous_system_number", "autonomous_system_organization"}, false, "", "", 0, ""},
+		{"ip2location", []string{"16777216", "16777471", "1.0.0.0/24", "13335", "CloudFlare Inc."}, true, "1.0.0.0", "1.0.0.255", 13335, "CloudFlare Inc."},
+		{"ip2location header", []string{"ip_from", "ip_to", "cidr", "asn", "as"}, false, "", "", 0, ""},
+		{"iptoasn", []string{"1.0.4.0", "1.0.7.255", "38803", "AU", "WPL-AS-AP Wirefreebroadband Pty Ltd"}, true, "1.0.4.0", "1.0.7.255", 38803, "WPL-AS-AP Wirefreebroadband Pty Ltd"},
+		{"iptoasn not routed", []string{"1.0.8.0", "1.0.15.255", "0", "None", "Not routed"}, false, "", "", 0, ""},
+		{"too short", []string{"1.0.0.0/24", "13335"}, false, "", "", 0, ""},
+	}
+	for _, test := range tests {
+		entry, ok := parseASNRow(test.row)
+		if ok != test.ok {
+			t.Errorf("%s: expected %t, got %t", test.name, test.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if entry.start.String() != test.start || entry.end.String() != test.end || entry.asn != test.asn || entry.organization != test.org {
+			t.Errorf("%s: unexpected range %+v", test.name, entry)
+		}
+	}
+}
+
+func TestASNDatabaseLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		lookups  map[string]int
+		notFound []string
+	}{
+		{
+			name:     "maxmind",
+			file:     "asn.csv",
+			content:  "network,autonomous_system_number,autonomous_system_organization\n1.0.0.0/24,13335,CLOUDFLARENET\n2001:db8::/32,64500,EXAMPLE\n",
+			lookups:  map[string]int{"1.0.0.0": 13335, "1.0.0.255": 13335, "::ffff:1.0.0.1": 13335, "2001:db8::1": 64500},
+			notFound: []string{"0.255.255.255", "1.0.1.0", "2001:db9::", "invalid"},
+		},
+		{
+			name:     "ip2location",
+			file:     "asn.csv",
+			content:  "\"ip_from\",\"ip_to\",\"cidr\",\"asn\",\"as\"\n\"16777216\",\"16777471\",\"1.0.0.0/24\",\"13335\",\"CloudFlare Inc.\"\n",
+			lookups:  map[string]int{"1.0.0.0": 13335, "1.0.0.255": 13335},
+			notFound: []string{"1.0.1.0", "::1"},
+		},
+		{
+			name:     "iptoasn",
+			file:     "asn.tsv",
+			content:  "1.0.4.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP\n1.0.16.0\t1.0.16.255\t2519\tJP\tVECTANT\n",
+			lookups:  map[string]int{"1.0.4.0": 38803, "1.0.7.255": 38803, "1.0.16.128": 2519},
+			notFound: []string{"1.0.8.0", "1.0.17.0", "2001:db8::1"},
+		},
+	}
+	for _, test := range tests {
+		database, err := LoadASNDatabase(writeTestFile(t, test.file, test.content))
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		for ipaddress, asn := range test.lookups {
+			if info, ok := database.Lookup(ipaddress); !ok || info.ASN != asn {
+				t.Errorf("%s: expected AS%d for %s, got %+v", test.name, asn, ipaddress, info)
+			}
+		}
+		for _, ipaddress := range test.notFound {
+			if info, ok := database.Lookup(ipaddress); ok {
+				t.Errorf("%s: expected no ASN for %s, got %+v", test.name, ipaddress, info)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package remover
+
+import (
+	"golang.org/x/exp/slices"
+	"sort"
+)
+
+/*
+Merges representatives of different IPs which share the same key (e.g. the same TLS endpoint) into one cluster, since
+the key identifies the same service. Existing clusters of merged representatives are folded into the new cluster. TLDs
+are always kept as non duplicates. The remaining representatives and the updated clusters are returned.
+*/
+func (p *Remover) mergeRepresentatives(representatives []string, duplicates []Duplicates, httpxEntries []SimpleHTTPXEntry,
+	keyType string, reason string, getKey func(input string) string) ([]string, []Duplicates) {
+	hostsPerKey := make(map[string][]string)
+	for _, input := range representatives {
+		if key := getKey(input); key != "" {
+			hostsPerKey[key] = append(hostsPerKey[key], input)
+		}
+	}
+	keys := make([]string, 0, len(hostsPerKey))
+	for key := range hostsPerKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	merged := make(map[string]bool)
+	for _, key := range keys {
+		if len(hostsPerKey[key]) < 2 {
+			continue
+		}
+		var candidates []SimpleHTTPXEntry
+		for _, input := range hostsPerKey[key] {
+			candidates = append(candidates, getHTTPXEntryForInput(httpxEntries, input))
+		}
+		tlds := make(map[string]SimpleHTTPXEntry)
+		representative := getBestDuplicateMatch(candidates, p.options.Project, tlds)
+		if representative.isEmpty() {
+			representative = candidates[0]
+		}
+		cluster := getDuplicate(representative)
+		cluster.KeyType = keyType
+		cluster.Key = key
+		cluster.Reason = reason
+		for _, candidate := range candidates {
+			host, _ := getHostAndPort(candidate.Input)
+			if _, ok := tlds[host]; ok || candidate.Input == representative.Input {
+				continue
+			}
+			cluster.DuplicateHosts = AppendIfMissing(cluster.DuplicateHosts, candidate.Input)
+			merged[candidate.Input] = true
+		}
+		if len(cluster.DuplicateHosts) == 0 {
+			continue
+		}
+		log.Infof("Merging %d hosts with %s as %s", len(cluster.DuplicateHosts), cluster.Hostname, reason)
+		var remaining []Duplicates
+		for _, existing := range duplicates {
+			if existing.Hostname == cluster.Hostname || ExistsInArray(cluster.DuplicateHosts, existing.Hostname) {
+				cluster = foldCluster(cluster, existing)
+				if existing.Hostname == cluster.Hostname {
+					cluster.Ports = existing.Ports
+				}
+				continue
+			}
+			remaining = append(remaining, existing)
+		}
+		duplicates = append(remaining, cluster)
+	}
+
+	var remainingRepresentatives []string
+	for _, input := range representatives {
+		if !merged[input] {
+			remainingRepresentatives = append(remainingRepresentatives, input)
+		}
+	}
+	return remainingRepresentatives, duplicates
+}
+
+/*
+Returns the key used to merge representatives with the same TLS endpoint. The key consists of the group of the entry
+(see getGroupKey), the TLS fingerprint and the body hash. Entries without TLS data and entries with a status which
+should never be merged have no key.
+*/
+func (p *Remover) getTLSClusterKey(entry SimpleHTTPXEntry) string {
+	if entry.JARM == "" || entry.CertificateHash == "" || slices.Contains(appConfig.NeverMergeStatus, entry.Status) {
+		return ""
+	}
+	return p.getGroupKey(entry) + "|" + entry.tlsFingerprint() + "|" + entry.BodyHash
+}
+
+/*
+Returns the key used to merge representatives with the same final CNAME target. Since different tenants of a SaaS
+endpoint share the same target, the key also consists of the group of the entry (see getGroupKey) and the body hash.
+Hosts without HTTP response, without CNAME and with a status which should never be merged have no key.
+*/
+func (p *Remover) getCNAMEClusterKey(entry SimpleHTTPXEntry, records []DNSRecord) string {
+	if entry.BodyHash == "" || slices.Contains(appConfig.NeverMergeStatus, entry.Status) {
+		return ""
+	}
+	host, _ := getHostAndPort(entry.Input)
+	target := getCNAMETarget(GetDNSRecordForHostname(records, host))
+	if target == "" {
+		return ""
+	}
+	return p.getGroupKey(entry) + "|" + target + "|" + entry.BodyHash
+}
+
+// Returns the HTTPX entry of the input, or an entry only consisting of the input if it has no HTTP response.
+func getHTTPXEntryForInput(entries []SimpleHTTPXEntry, input string) SimpleHTTPXEntry {
+	for _, entry := range entries {
+		if entry.Input == input {
+			return entry
+		}
+	}
+	return SimpleHTTPXEntry{Input: input}
+}
+
+// Only keeps the ports of hosts which are still used.
+func getHostsPortsForHosts(hostsPorts []HostPorts, hosts []string) []HostPorts {
+	var result []HostPorts
+	for _, hostPorts := range hostsPorts {
+		if ExistsInArray(hosts, hostPorts.Host) {
+			result = append(result, hostPorts)
+		}
+	}
+	return result
+}
+
+// Only keeps the DNS records of hosts which are still used, independent of the port of the host.
+func getDNSRecordsForHosts(records []DNSRecord, hosts []string) []DNSRecord {
+	usedHosts := make(map[string]bool)
+	for _, input := range hosts {
+		host, _ := getHostAndPort(input)
+		usedHosts[host] = true
+	}
+	var result []DNSRecord
+	for _, record := range records {
+		if usedHosts[record.Host] {
+			result = append(result, record)
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package remover
+
+import (
+	"testing"
+)
+
+func TestMergeRepresentativesAcrossIPs(t *testing.T) {
+	entries := []SimpleHTTPXEntry{
+		{Input: "www.example.com", Host: "1.1.1.1", BodyHash: "111", JARM: "jarm", CertificateHash: "cert"},
+		{Input: "shop.example.com", Host: "2.2.2.2", BodyHash: "111", JARM: "jarm", CertificateHash: "cert"},
+		{Input: "other.example.com", Host: "3.3.3.3", BodyHash: "222", JARM: "jarm", CertificateHash: "cert"},
+	}
+	existing := getDuplicate(entries[1])
+	existing.DuplicateHosts = []string{"shop2.example.com"}
+	existing.Reason = "identical body hash"
+
+	p := &Remover{options: &Options{Project: "example.com"}}
+	representatives, duplicates := p.mergeRepresentatives([]string{"www.example.com", "shop.example.com", "other.example.com"},
+		[]Duplicates{existing}, entries, "tls", "same TLS endpoint", func(input string) string {
+			entry := getHTTPXEntryForInput(entries, input)
+			return entry.tlsFingerprint() + "|" + entry.BodyHash
+		})
+
+	if len(representatives) != 2 || ExistsInArray(representatives, "shop.example.com") {
+		t.Errorf("expected shop.example.com to be merged, got %v", representatives)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected the existing cluster to be folded into the new one, got %+v", duplicates)
+	}
+	cluster := duplicates[0]
+	if cluster.Hostname != "www.example.com" || len(cluster.DuplicateHosts) != 2 || cluster.KeyType != "tls" {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+	if cluster.Reason != "identical body hash, same TLS endpoint" {
+		t.Errorf("expected the reasons to be combined, got %s", cluster.Reason)
+	}
+}
+
+func TestTLSClusterKeyUsesGroupKey(t *testing.T) {
+	appConfig.NeverMergeStatus = []int{401}
+	defer func() { appConfig.NeverMergeStatus = nil }()
+	p := &Remover{options: &Options{StatusGrouping: "code", Scope: "port"}}
+	entry := SimpleHTTPXEntry{Input: "www.example.com", URL: "https://www.example.com", Status: 200, BodyHash: "111",
+		JARM: "jarm", CertificateHash: "cert"}
+	key := p.getTLSClusterKey(entry)
+	if key == "" {
+		t.Fatalf("expected a key for %+v", entry)
+	}
+
+	otherStatus := entry
+	otherStatus.Status = 302
+	otherPort := entry
+	otherPort.URL = "https://www.example.com:8443"
+	for _, other := range []SimpleHTTPXEntry{otherStatus, otherPort} {
+		if otherKey := p.getTLSClusterKey(other); otherKey == key {
+			t.Errorf("expected a different key for %+v, got %s", other, otherKey)
+		}
+	}
+
+	neverMerged := entry
+	neverMerged.Status = 401
+	withoutTLS := entry
+	withoutTLS.JARM = ""
+	for _, other := range []SimpleHTTPXEntry{neverMerged, withoutTLS} {
+		if otherKey := p.getTLSClusterKey(other); otherKey != "" {
+			t.Errorf("expected no key for %+v, got %s", other, otherKey)
+		}
+	}
+}
+
+func TestCNAMEClusterKeyUsesGroupAndBodyHash(t *testing.T) {
+	records := []DNSRecord{
+		{Host: "a.example.com", CNAMEs: []string{"tenant.saas.net."}},
+		{Host: "b.example.com", CNAMEs: []string{"tenant.saas.net"}},
+		{Host: "c.example.com", CNAMEs: []string{"tenant.saas.net"}},
+		{Host: "d.example.com", CNAMEs: []string{"tenant.saas.net"}},
+		{Host: "e.example.com"},
+	}
+	p := &Remover{options: &Options{StatusGrouping: "code", Scope: "port"}}
+	getKey := func(input string, status int, bodyHash string) string {
+		return p.getCNAMEClusterKey(SimpleHTTPXEntry{Input: input, URL: "https://" + input, Status: status, BodyHash: bodyHash}, records)
+	}
+
+	key := getKey("a.example.com", 200, "111")
+	if key == "" || getKey("b.example.com", 200, "111") != key {
+		t.Errorf("expected the same key for the same CNAME target, status and body hash, got %s", key)
+	}
+	if getKey("c.example.com", 200, "222") == key || getKey("d.example.com", 404, "111") == key {
+		t.Errorf("expected different keys for a different body hash or status")
+	}
+	if otherKey := getKey("e.example.com", 200, "111"); otherKey != "" {
+		t.Errorf("expected no key without CNAME, got %s", otherKey)
+	}
+	if otherKey := p.getCNAMEClusterKey(SimpleHTTPXEntry{Input: "a.example.com"}, records); otherKey != "" {
+		t.Errorf("expected no key without HTTP response, got %s", otherKey)
+	}
+}
@@ -0,0 +1,33 @@
+package remover
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFileDetectsCompressionByMagicBytes(t *testing.T) {
+	content := []byte(`{"input":"www.example.com","host":"10.0.0.1"}` + "\n")
+	for _, extension := range []string{"", ".gz", ".zst"} {
+		data, err := compressForFile("input.json"+extension, content)
+		if err != nil {
+			t.Fatalf("compressing %s failed: %v", extension, err)
+		}
+		if extension != "" && bytes.Equal(data, content) {
+			t.Errorf("expected the content to be compressed for %s", extension)
+		}
+		// Written without extension, the compression must be detected by the content
+		filename := filepath.Join(t.TempDir(), "input.json")
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadFile(filename)
+		if err != nil {
+			t.Fatalf("reading %s failed: %v", extension, err)
+		}
+		if !bytes.Equal(read, content) {
+			t.Errorf("expected the original content for %s, got %q", extension, read)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package remover
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// A row of the tabular outputs, one for each host.
+type resultRow interface {
+	header() []string
+	values() []string
+}
+
+type HostRow struct {
+	ClusterID      string
+	Host           string
+	IP             string
+	URL            string
+	Status         int
+	Representative string
+	KeyType        string
+	Reason         string
+	InputFile      string
+}
+
+func (row HostRow) header() []string {
+	return []string{"ClusterID", "Host", "IP", "URL", "Status", "Representative", "KeyType", "Reason", "InputFile"}
+}
+
+func (row HostRow) values() []string {
+	status := ""
+	if row.Status != 0 {
+		status = strconv.Itoa(row.Status)
+	}
+	return []string{row.ClusterID, row.Host, row.IP, row.URL, status, row.Representative, row.KeyType, row.Reason, row.InputFile}
+}
+
+type DNSRow struct {
+	ClusterID      string
+	Representative string
+	KeyType        string
+	Reason         string
+	DNSRecord
+}
+
+func (row DNSRow) header() []string {
+	return []string{"ClusterID", "Host", "Representative", "KeyType", "Reason", "IPv4", "IPv6", "CNAME", "MX", "NS", "TXT", "SOA", "TTL", "ASN", "Whois"}
+}
+
+func (row DNSRow) values() []string {
+	var asns []string
+	for _, asn := range row.ASNs {
+		asns = append(asns, strconv.Itoa(asn.ASN))
+	}
+	ttl := ""
+	if row.TTL != 0 {
+		ttl = strconv.Itoa(row.TTL)
+	}
+	return []string{row.ClusterID, row.Host, row.Representative, row.KeyType, row.Reason, strings.Join(row.IPv4Addresses, ";"),
+		strings.Join(row.IPv6Addresses, ";"), strings.Join(row.CNAMEs, ";"), strings.Join(row.MXRecords, ";"),
+		strings.Join(row.NSRecords, ";"), strings.Join(row.TXTRecords, ";"), strings.Join(row.SOARecords, ";"), ttl,
+		strings.Join(asns, ";"), row.WhoisInfo}
+}
+
+// Returns one row for the representative and each duplicate host of every cluster.
+func getDuplicateRows(duplicates []Duplicates) []HostRow {
+	var rows []HostRow
+	for _, duplicate := range duplicates {
+		rows = append(rows, HostRow{
+			ClusterID:      duplicate.ClusterID,
+			Host:           duplicate.Hostname,
+			IP:             duplicate.IP,
+			URL:            duplicate.URL,
+			Status:         duplicate.Status,
+			Representative: duplicate.Hostname,
+			KeyType:        duplicate.KeyType,
+			Reason:         duplicate.Reason,
+			InputFile:      duplicate.InputFiles[duplicate.Hostname],
+		})
+		for _, duplicateHost := range duplicate.DuplicateHosts {
+			rows = append(rows, HostRow{
+				ClusterID:      duplicate.ClusterID,
+				Host:           duplicateHost,
+				IP:             duplicate.IP,
+				Representative: duplicate.Hostname,
+				KeyType:        duplicate.KeyType,
+				Reason:         duplicate.Reason,
+				InputFile:      duplicate.InputFiles[duplicateHost],
+			})
+		}
+	}
+	return rows
+}
+
+// Returns one row for each clean host. Hosts representing a cluster carry its ID and matching reason.
+func getCleanHostRows(cleanedInputs []string, duplicates []Duplicates, httpxEntries []SimpleHTTPXEntry) []HostRow {
+	clusters := getClusterRowsPerHost(duplicates)
+	var rows []HostRow
+	for _, input := range cleanedInputs {
+		row := HostRow{Host: input, Representative: input}
+		if cluster, ok := clusters[input]; ok {
+			row.ClusterID = cluster.ClusterID
+			row.KeyType = cluster.KeyType
+			row.Reason = cluster.Reason
+		}
+		for _, entry := range httpxEntries {
+			if entry.Input == input {
+				row.IP = entry.Host
+				row.URL = entry.URL
+				row.Status = entry.Status
+				row.InputFile = entry.InputFile
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Returns one row for each DNS record, including the cluster the host belongs to.
+func getDNSRows(dnsRecords []DNSRecord, duplicates []Duplicates) []DNSRow {
+	clusters := getClusterRowsPerHost(duplicates)
+	var rows []DNSRow
+	for _, record := range dnsRecords {
+		row := DNSRow{Representative: record.Host, DNSRecord: record}
+		if cluster, ok := clusters[record.Host]; ok {
+			row.ClusterID = cluster.ClusterID
+			row.Representative = cluster.Representative
+			row.KeyType = cluster.KeyType
+			row.Reason = cluster.Reason
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Maps every host of the clusters (with and without port) to its row, the first occurrence is used.
+func getClusterRowsPerHost(duplicates []Duplicates) map[string]HostRow {
+	clusters := make(map[string]HostRow)
+	for _, row := range getDuplicateRows(duplicates) {
+		host, _ := getHostAndPort(row.Host)
+		for _, key := range []string{row.Host, host} {
+			if _, ok := clusters[key]; !ok {
+				clusters[key] = row
+			}
+		}
+	}
+	return clusters
+}
+
+/*
+Writes the rows to the findings file with the provided name in the requested format. JSON is written as indented
+array, JSONL as one object per line and CSV or TSV with a header line.
+*/
+func writeResultRows[T resultRow](p *Remover, name string, rows []T) {
+	var data []byte
+	switch p.options.Format {
+	case "json":
+		data, _ = json.MarshalIndent(rows, "", " ")
+	case "jsonl":
+		var buffer bytes.Buffer
+		for _, row := range rows {
+			line, _ := json.Marshal(row)
+			buffer.Write(line)
+			buffer.WriteString("\n")
+		}
+		data = buffer.Bytes()
+	default:
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		if p.options.Format == "tsv" {
+			writer.Comma = '\t'
+		}
+		var row T
+		writer.Write(row.header())
+		for _, row := range rows {
+			writer.Write(row.values())
+		}
+		writer.Flush()
+		data = buffer.Bytes()
+	}
+	WriteToTextFileInProject(p.getFindingsFile(name+"."+p.options.Format), string(data))
+}
@@ -0,0 +1,81 @@
+package remover
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// Headers which identify the application serving the response
+	fingerprintHeaders = []string{"server", "x-powered-by", "x-aspnet-version", "x-generator", "via", "content-security-policy", "set-cookie"}
+	cspNonceRegex      = regexp.MustCompile(`'nonce-[^']*'`)
+)
+
+/*
+Reads the response headers from the HTTPX entry. Depending on the HTTPX version and flags they are either provided as
+map (header) or as raw string (raw_header). The names are normalized to lower case with dashes.
+*/
+func getHeadersFromHTTPX(entryValues map[string]interface{}) map[string][]string {
+	headers := make(map[string][]string)
+	if headerValues, ok := entryValues["header"].(map[string]interface{}); ok {
+		for name, value := range headerValues {
+			name = normalizeHeaderName(name)
+			if values, ok := value.([]interface{}); ok {
+				for _, v := range values {
+					if s, ok := v.(string); ok {
+						headers[name] = append(headers[name], s)
+					}
+				}
+			} else if s, ok := value.(string); ok {
+				headers[name] = append(headers[name], s)
+			}
+		}
+	} else if rawHeader, ok := entryValues["raw_header"].(string); ok {
+		for _, line := range strings.Split(strings.ReplaceAll(rawHeader, "\r\n", "\n"), "\n") {
+			name, value, found := strings.Cut(line, ":")
+			if !found || strings.HasPrefix(line, "HTTP/") {
+				continue
+			}
+			name = normalizeHeaderName(name)
+			headers[name] = append(headers[name], strings.TrimSpace(value))
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+func normalizeHeaderName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+}
+
+/*
+Creates a fingerprint from the headers identifying the application. Only the names of cookies are used and nonces are
+removed from the CSP, since the values change with every request. Returns an empty string if no headers are available.
+*/
+func getHeaderFingerprint(headers map[string][]string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, name := range fingerprintHeaders {
+		var values []string
+		for _, value := range headers[name] {
+			switch name {
+			case "set-cookie":
+				value, _, _ = strings.Cut(value, "=")
+			case "content-security-policy":
+				value = cspNonceRegex.ReplaceAllString(value, "'nonce'")
+			}
+			values = AppendIfMissing(values, strings.TrimSpace(value))
+		}
+		sort.Strings(values)
+		parts = append(parts, name+":"+strings.Join(values, ","))
+	}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(hash[:])
+}
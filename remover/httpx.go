@@ -0,0 +1,202 @@
+package remover
+
+import (
+	"encoding/json"
+	"github.com/antchfx/jsonquery"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Field added to every HTTPX entry, stating the input file it was read from
+const inputFileField = "_input_file"
+
+type HTTPXRecord struct {
+	Line   string
+	File   string
+	Values map[string]interface{}
+}
+
+/*
+Reads the HTTPX records of all input files and merges them into one dataset. If the same URL (or input if no URL is
+provided) is contained in multiple input files, the conflict is resolved using the merge policy:
+  - newest: the record with the newest timestamp is used (the later file if no timestamps are provided)
+  - 2xx: a record with a 2xx status code is preferred, if both or none have one the newest is used
+  - first: the first record read is used
+
+Multiple records of the same URL within one file are kept as they are.
+*/
+func LoadHTTPXRecords(filenames []string, policy string) []HTTPXRecord {
+	var records []HTTPXRecord
+	indexes := make(map[string][]int)
+	removed := make(map[int]bool)
+	for _, filename := range filenames {
+		data, err := ReadFile(filename)
+		if err != nil {
+			log.Fatalf("Reading JSON input file failed: %s %s", err.Error(), filename)
+		}
+		for _, line := range strings.Split(getJSONLFromJSON(string(data)), "\n") {
+			line = strings.TrimSpace(line)
+			var entryValues map[string]interface{}
+			if line == "" || json.Unmarshal([]byte(line), &entryValues) != nil {
+				continue
+			}
+			record := HTTPXRecord{Line: line, File: filename, Values: entryValues}
+			key := getHTTPXRecordKey(entryValues)
+			existing := indexes[key]
+			if len(existing) == 0 || records[existing[0]].File == filename {
+				indexes[key] = append(existing, len(records))
+				records = append(records, record)
+				continue
+			}
+			if isPreferredHTTPXRecord(record, records[existing[0]], policy) {
+				log.Debugf("Using record for %s from %s instead of %s", key, record.File, records[existing[0]].File)
+				for _, index := range existing {
+					removed[index] = true
+				}
+				indexes[key] = []int{len(records)}
+				records = append(records, record)
+			}
+		}
+	}
+	var merged []HTTPXRecord
+	for index, record := range records {
+		if !removed[index] {
+			merged = append(merged, record)
+		}
+	}
+	return merged
+}
+
+func getHTTPXRecordKey(entryValues map[string]interface{}) string {
+	if url, ok := entryValues["url"].(string); ok && url != "" {
+		return url
+	}
+	input, _ := entryValues["input"].(string)
+	return input
+}
+
+func isPreferredHTTPXRecord(record HTTPXRecord, existing HTTPXRecord, policy string) bool {
+	switch policy {
+	case "first":
+		return false
+	case "2xx":
+		recordSuccess := isSuccessStatus(record.Values)
+		existingSuccess := isSuccessStatus(existing.Values)
+		if recordSuccess != existingSuccess {
+			return recordSuccess
+		}
+	}
+	recordTime, recordOk := getHTTPXTimestamp(record.Values)
+	existingTime, existingOk := getHTTPXTimestamp(existing.Values)
+	if recordOk && existingOk {
+		return !recordTime.Before(existingTime)
+	}
+	return true
+}
+
+func isSuccessStatus(entryValues map[string]interface{}) bool {
+	status, _ := entryValues["status_code"].(float64)
+	return status >= 200 && status < 300
+}
+
+func getHTTPXTimestamp(entryValues map[string]interface{}) (time.Time, bool) {
+	value, ok := entryValues["timestamp"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+// Creates the document used for querying from the merged records. The input file of every record is added.
+func GetDocumentFromHTTPXRecords(records []HTTPXRecord) *jsonquery.Node {
+	var entries []map[string]interface{}
+	for _, record := range records {
+		entryValues := make(map[string]interface{}, len(record.Values)+1)
+		for key, value := range record.Values {
+			entryValues[key] = value
+		}
+		entryValues[inputFileField] = filepath.Base(record.File)
+		entries = append(entries, entryValues)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		log.Fatalf("Creating HTTPX document failed: %s", err)
+	}
+	if entries == nil {
+		data = []byte("[]")
+	}
+	input, err := jsonquery.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		log.Fatalf("Creating HTTPX document failed: %s", err)
+	}
+	return input
+}
+
+/*
+Writes the original JSON lines of the records whose input (hostname) is contained in the provided hosts to the output
+file. The lines are written untouched, so that they can be consumed by other tools. Returns the number of written lines.
+*/
+func WriteFilteredJSONLines(records []HTTPXRecord, outputFile string, hosts []string) int {
+	var lines []string
+	for _, record := range records {
+		if input, ok := record.Values["input"].(string); ok && ExistsInArray(hosts, input) {
+			lines = append(lines, record.Line)
+		}
+	}
+	WriteToTextFileInProject(outputFile, strings.Join(lines, "\n")+"\n")
+	return len(lines)
+}
+
+// Returns the HTTPX input files matching the configured paths (globs are allowed), relative to the recon folder.
+func (p *Remover) getHTTPXInputFiles() []string {
+	var inputFiles []string
+	locations := []string{appConfig.HttpxDomainsFile, appConfig.HttpxIPsFile}
+	locations = append(locations, appConfig.HttpxInputs...)
+	for index, location := range locations {
+		if location == "" {
+			continue
+		}
+		location = strings.Replace(location, "{project_name}", p.options.Project, -1)
+		if !filepath.IsAbs(location) {
+			location = p.options.BaseFolder + "recon/" + location
+		}
+		matches, err := filepath.Glob(location)
+		if err != nil {
+			log.Errorf("Invalid HTTPX input %s: %s", location, err)
+			continue
+		}
+		// The HTTPX domains input is required, the others are used if they exist
+		if len(matches) == 0 && index == 0 {
+			log.Fatalf("HTTPX domains input %s doesn't exist", location)
+		}
+		for _, match := range matches {
+			if !ExistsInArray(inputFiles, match) {
+				log.Infof("Using HTTPX input %s", match)
+				inputFiles = append(inputFiles, match)
+			}
+		}
+	}
+	if len(inputFiles) == 0 {
+		log.Fatalf("No HTTPX input found in %s", p.options.BaseFolder+"recon/")
+	}
+	return inputFiles
+}
+
+// Returns the input file of the representative and every duplicate host which has an HTTPX entry.
+func getInputFilesForDuplicate(duplicate Duplicates, httpxEntries []SimpleHTTPXEntry) map[string]string {
+	inputFiles := make(map[string]string)
+	for _, host := range append([]string{duplicate.Hostname}, duplicate.DuplicateHosts...) {
+		if entry := getHTTPXEntryForInput(httpxEntries, host); entry.InputFile != "" {
+			inputFiles[host] = entry.InputFile
+		}
+	}
+	if len(inputFiles) == 0 {
+		return nil
+	}
+	return inputFiles
+}
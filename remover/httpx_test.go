@@ -0,0 +1,73 @@
+package remover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const httpxDomains = `{"input":"www.example.com","url":"https://www.example.com","status_code":200,"timestamp":"2024-01-02T10:00:00Z"}
+{"input":"a.example.com","url":"https://a.example.com","status_code":503,"timestamp":"2024-01-01T10:00:00Z"}
+{"input":"b.example.com","url":"https://b.example.com","status_code":200}
+{"input":"b.example.com","url":"https://b.example.com","status_code":404}
+`
+
+const httpxRescan = `{"input":"www.example.com","url":"https://www.example.com","status_code":502,"timestamp":"2024-01-01T10:00:00Z"}
+{"input":"a.example.com","url":"https://a.example.com","status_code":200,"timestamp":"2024-01-03T10:00:00Z"}
+`
+
+func TestLoadHTTPXRecordsConflictPolicy(t *testing.T) {
+	domainsFile := writeTestFile(t, "httpx_domains.json", httpxDomains)
+	rescanFile := writeTestFile(t, "httpx_rescan.json", httpxRescan)
+
+	tests := []struct {
+		policy string
+		status map[string]float64
+	}{
+		{policy: "newest", status: map[string]float64{"www.example.com": 200, "a.example.com": 200}},
+		{policy: "2xx", status: map[string]float64{"www.example.com": 200, "a.example.com": 200}},
+		{policy: "first", status: map[string]float64{"www.example.com": 200, "a.example.com": 503}},
+	}
+	for _, test := range tests {
+		records := LoadHTTPXRecords([]string{domainsFile, rescanFile}, test.policy)
+		// Both records of b.example.com are from the same file and are kept
+		if len(records) != 4 {
+			t.Errorf("%s: expected 4 records, got %d", test.policy, len(records))
+		}
+		for _, record := range records {
+			input := record.Values["input"].(string)
+			if expected, ok := test.status[input]; ok && record.Values["status_code"] != expected {
+				t.Errorf("%s: expected status %v for %s, got %v", test.policy, expected, input, record.Values["status_code"])
+			}
+		}
+	}
+
+	records := LoadHTTPXRecords([]string{rescanFile, domainsFile}, "2xx")
+	for _, record := range records {
+		if record.Values["input"] == "www.example.com" && record.File != domainsFile {
+			t.Errorf("expected the 2xx record of www.example.com from %s, got %s", domainsFile, record.File)
+		}
+	}
+}
+
+func TestWriteFilteredJSONLinesKeepsOriginalLines(t *testing.T) {
+	records := LoadHTTPXRecords([]string{writeTestFile(t, "httpx_domains.json", httpxDomains)}, "first")
+	outputFile := filepath.Join(t.TempDir(), "httpx_clean.json")
+
+	written := WriteFilteredJSONLines(records, outputFile, []string{"www.example.com", "b.example.com"})
+
+	if written != 3 {
+		t.Errorf("expected 3 written lines, got %d", written)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"input":"www.example.com","url":"https://www.example.com","status_code":200,"timestamp":"2024-01-02T10:00:00Z"}
+{"input":"b.example.com","url":"https://b.example.com","status_code":200}
+{"input":"b.example.com","url":"https://b.example.com","status_code":404}
+`
+	if string(data) != expected {
+		t.Errorf("expected the original lines of the hosts, got %s", data)
+	}
+}
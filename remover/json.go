@@ -1,17 +1,21 @@
 package remover
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/antchfx/jsonquery"
-	"os"
+	"golang.org/x/exp/slices"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 func GetDocumentFromFile(filename string) *jsonquery.Node {
-	data, err := os.ReadFile(filename)
+	data, err := ReadFile(filename)
 	if err != nil {
 		log.Fatalf("Reading JSON input file failed: %s %s", err.Error(), filename)
 	}
-	jsonlString := ConvertJSONLtoJSON(string(data))
+	jsonlString := ConvertJSONLtoJSON(getJSONLFromJSON(string(data)))
 	jsonReader := strings.NewReader(jsonlString)
 	input, err := jsonquery.Parse(jsonReader)
 	if err != nil {
@@ -21,6 +25,44 @@ func GetDocumentFromFile(filename string) *jsonquery.Node {
 	return input
 }
 
+// Reads multiple JSON(L) files into one document.
+func GetDocumentFromFiles(filenames []string) *jsonquery.Node {
+	var jsonlString string
+	for _, filename := range filenames {
+		data, err := ReadFile(filename)
+		if err != nil {
+			log.Fatalf("Reading JSON input file failed: %s %s", err.Error(), filename)
+		}
+		jsonlString = jsonlString + getJSONLFromJSON(string(data)) + "\n"
+	}
+	input, err := jsonquery.Parse(strings.NewReader(ConvertJSONLtoJSON(jsonlString)))
+	if err != nil {
+		log.Fatalf("Reading JSON input files failed: %s %s", err.Error(), strings.Join(filenames, ", "))
+	}
+	return input
+}
+
+// JSON arrays are converted to JSON lines, JSON lines are returned as they are.
+func getJSONLFromJSON(data string) string {
+	if !strings.HasPrefix(strings.TrimSpace(data), "[") {
+		return data
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return data
+	}
+	// Pretty printed elements are compacted, since every element must be on a single line
+	var lines []string
+	for _, entry := range entries {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, entry); err != nil {
+			return data
+		}
+		lines = append(lines, compacted.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
 func GetHTTPXEntryForIPAddress(document *jsonquery.Node, ipaddress string) []SimpleHTTPXEntry {
 	var entries []SimpleHTTPXEntry
 	entriesForHost, error := jsonquery.QueryAll(document, "//*[host='"+ipaddress+"']")
@@ -37,40 +79,83 @@ func GetHTTPXEntryForIPAddress(document *jsonquery.Node, ipaddress string) []Sim
 	return entries
 }
 
-func GetDNSRecordForIPAddress(document *jsonquery.Node, ipaddress string) DNSRecord {
-	entriesForHost, error := jsonquery.Query(document, "//*/a[contains(.,'"+ipaddress+"')]")
-
-	entry := DNSRecord{}
+func GetAllHTTPXEntries(document *jsonquery.Node) []SimpleHTTPXEntry {
+	var entries []SimpleHTTPXEntry
+	allEntries, error := jsonquery.QueryAll(document, "/*")
+	if error != nil {
+		log.Errorf("Querying JSON error   #%v ", error)
+	}
+	for _, hostEntry := range allEntries {
+		if entryValues, ok := hostEntry.Value().(map[string]interface{}); ok {
+			entries = append(entries, CreateSimpleHostEntryFromHTTPX(entryValues))
+		}
+	}
+	return entries
+}
 
+func GetAllDNSRecords(document *jsonquery.Node) []DNSRecord {
+	var records []DNSRecord
+	allRecords, error := jsonquery.QueryAll(document, "/*")
 	if error != nil {
 		log.Errorf("Querying JSON error   #%v ", error)
-	} else {
-		if entriesForHost != nil {
-			log.Debugf("Entries for host %s are # %d", ipaddress, entriesForHost.Type)
-			entry = CreateSimpleDNSEntryFromDPUX(entriesForHost.Parent)
-		} else {
-			log.Errorf("Provided entries for host %s doesn't have a parent", ipaddress)
+	}
+	for _, record := range allRecords {
+		entry := CreateSimpleDNSEntryFromDPUX(record)
+		if entry.Host != "" {
+			records = append(records, entry)
 		}
 	}
-	return entry
+	return records
 }
 
-func GetDNSRecordForHostname(document *jsonquery.Node, hostname string) DNSRecord {
-	entriesForHost, error := jsonquery.Query(document, "//*[host='"+hostname+"']")
+// Returns all DNS records which resolve exactly to the IP address (IPv4 or IPv6).
+func GetDNSRecordsForIPAddress(records []DNSRecord, ipaddress string) []DNSRecord {
+	var entries []DNSRecord
+	for _, record := range records {
+		if slices.Contains(record.IPv4Addresses, ipaddress) || slices.Contains(record.IPv6Addresses, ipaddress) {
+			entries = append(entries, record)
+		}
+	}
+	if len(entries) == 0 {
+		log.Debugf("No DNS records found for IP %s", ipaddress)
+	}
+	return entries
+}
 
-	entry := DNSRecord{}
+func GetDNSRecordForHostname(records []DNSRecord, hostname string) DNSRecord {
+	for _, record := range records {
+		if record.Host == hostname {
+			return record
+		}
+	}
+	log.Debugf("No DNS record found for host %s", hostname)
+	return DNSRecord{}
+}
 
-	if error != nil {
-		log.Errorf("Querying JSON error   #%v ", error)
-	} else {
-		if entriesForHost != nil {
-			log.Debugf("Entries for host %s are # %d", hostname, entriesForHost.Type)
-			entry = CreateSimpleDNSEntryFromDPUX(entriesForHost)
-		} else {
-			log.Errorf("Provided entries for host %s doesn't have a parent", hostname)
+// Merges the records of the same hostname from different DNS inputs into one record.
+func MergeDNSRecords(records []DNSRecord) []DNSRecord {
+	var merged []DNSRecord
+	indexes := make(map[string]int)
+	for _, record := range records {
+		index, ok := indexes[record.Host]
+		if !ok {
+			indexes[record.Host] = len(merged)
+			merged = append(merged, record)
+			continue
+		}
+		existing := &merged[index]
+		existing.IPv4Addresses = AppendSliceIfMissing(existing.IPv4Addresses, record.IPv4Addresses)
+		existing.IPv6Addresses = AppendSliceIfMissing(existing.IPv6Addresses, record.IPv6Addresses)
+		existing.CNAMEs = AppendSliceIfMissing(existing.CNAMEs, record.CNAMEs)
+		existing.MXRecords = AppendSliceIfMissing(existing.MXRecords, record.MXRecords)
+		existing.NSRecords = AppendSliceIfMissing(existing.NSRecords, record.NSRecords)
+		existing.TXTRecords = AppendSliceIfMissing(existing.TXTRecords, record.TXTRecords)
+		existing.SOARecords = AppendSliceIfMissing(existing.SOARecords, record.SOARecords)
+		if existing.TTL == 0 {
+			existing.TTL = record.TTL
 		}
 	}
-	return entry
+	return merged
 }
 
 func CreateSimpleHostEntryFromHTTPX(entryValues map[string]interface{}) SimpleHTTPXEntry {
@@ -105,44 +190,128 @@ func CreateSimpleHostEntryFromHTTPX(entryValues map[string]interface{}) SimpleHT
 	if url, ok := entryValues["url"].(string); ok {
 		entry.URL = url
 	}
+	if technologies, ok := entryValues["tech"].([]interface{}); ok {
+		for _, technology := range technologies {
+			if name, ok := technology.(string); ok {
+				entry.Technologies = AppendIfMissing(entry.Technologies, name)
+			}
+		}
+		sort.Strings(entry.Technologies)
+	}
+	if inputFile, ok := entryValues[inputFileField].(string); ok {
+		entry.InputFile = inputFile
+	}
+	if screenshotPath, ok := entryValues["screenshot_path"].(string); ok {
+		entry.ScreenshotPath = screenshotPath
+	}
+	setTLSDataFromHTTPX(&entry, entryValues)
+	entry.Headers = getHeadersFromHTTPX(entryValues)
+	entry.HeaderHash = getHeaderFingerprint(entry.Headers)
+	// If the raw body is available (-include-response) for all entries, our own hash of the normalized body is used.
+	if body, ok := entryValues["body"].(string); ok && body != "" {
+		if useNormalizedHash {
+			hostname, _ := getHostAndPort(entry.Input)
+			entry.BodyHash = getNormalizedBodyHash(body, hostname)
+		}
+		entry.Skeleton = getSkeletonFingerprint(body)
+	}
 	return entry
 }
 
 func CreateSimpleDNSEntryFromDPUX(record *jsonquery.Node) DNSRecord {
 	var entry DNSRecord
 	if entryValues, ok := record.Value().(map[string]interface{}); ok {
+		host, _ := entryValues["host"].(string)
+		entry = DNSRecord{
+			Host:          host,
+			IPv4Addresses: getStringsFromValue(entryValues["a"]),
+			IPv6Addresses: getStringsFromValue(entryValues["aaaa"]),
+			CNAMEs:        getStringsFromValue(entryValues["cname"]),
+			MXRecords:     getStringsFromValue(entryValues["mx"]),
+			NSRecords:     getStringsFromValue(entryValues["ns"]),
+			TXTRecords:    getStringsFromValue(entryValues["txt"]),
+			SOARecords:    getSOARecordsFromValue(entryValues["soa"]),
+		}
+		if ttl, ok := entryValues["ttl"].(float64); ok {
+			entry.TTL = int(ttl)
+		}
+	} else {
+		entry = DNSRecord{}
+	}
+	return entry
+}
 
-		var ip4Addresses []string
-		var ip6Addresses []string
-		host := entryValues["host"].(string)
-
-		if entries, ok := entryValues["a"].([]interface{}); ok {
-			for _, address := range entries {
-				if _, ok := address.(string); ok {
-					ip4Addresses = append(ip4Addresses, address.(string))
-				}
+// DNSx provides records either as single string or as array of strings.
+func getStringsFromValue(value interface{}) []string {
+	var values []string
+	if entries, ok := value.([]interface{}); ok {
+		for _, entry := range entries {
+			if s, ok := entry.(string); ok {
+				values = append(values, s)
 			}
-		} else if entry, ok := entryValues["a"].(string); ok {
-			ip4Addresses = append(ip4Addresses, entry)
 		}
+	} else if s, ok := value.(string); ok {
+		values = append(values, s)
+	}
+	return values
+}
 
-		if entries, ok := entryValues["aaaa"].([]interface{}); ok {
-			for _, address := range entries {
-				if _, ok := address.(string); ok {
-					ip6Addresses = append(ip6Addresses, address.(string))
+// Newer DNSx versions provide SOA records as objects, which are converted to the zone file representation.
+func getSOARecordsFromValue(value interface{}) []string {
+	entries, ok := value.([]interface{})
+	if !ok {
+		return getStringsFromValue(value)
+	}
+	var values []string
+	for _, entry := range entries {
+		if s, ok := entry.(string); ok {
+			values = append(values, s)
+		} else if soa, ok := entry.(map[string]interface{}); ok {
+			var parts []string
+			for _, field := range []string{"name", "ns", "mailbox", "serial", "refresh", "retry", "expire", "minttl"} {
+				switch fieldValue := soa[field].(type) {
+				case string:
+					parts = append(parts, fieldValue)
+				case float64:
+					parts = append(parts, strconv.FormatFloat(fieldValue, 'f', -1, 64))
 				}
 			}
-		} else if entry, ok := entryValues["aaaa"].(string); ok {
-			ip6Addresses = append(ip6Addresses, entry)
+			values = append(values, strings.Join(parts, " "))
 		}
+	}
+	return values
+}
 
-		entry = DNSRecord{
-			Host:          host,
-			IPv4Addresses: ip4Addresses,
-			IPv6Addresses: ip6Addresses,
+/*
+Groups the hostnames by the final target of their CNAME chain. Hosts pointing to the same (SaaS) endpoint are returned
+together, only targets with more than one host are returned.
+*/
+func GetCNAMEClusters(records []DNSRecord) []CNAMECluster {
+	hostsPerTarget := make(map[string][]string)
+	for _, record := range records {
+		if len(record.CNAMEs) == 0 {
+			continue
 		}
-	} else {
-		entry = DNSRecord{}
+		target := getCNAMETarget(record)
+		hostsPerTarget[target] = AppendIfMissing(hostsPerTarget[target], record.Host)
 	}
-	return entry
+	var clusters []CNAMECluster
+	for target, hosts := range hostsPerTarget {
+		if len(hosts) > 1 {
+			sort.Strings(hosts)
+			clusters = append(clusters, CNAMECluster{Target: target, Hosts: hosts})
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Target < clusters[j].Target
+	})
+	return clusters
+}
+
+// Returns the final target of the CNAME chain of the record or an empty string if it has no CNAME.
+func getCNAMETarget(record DNSRecord) string {
+	if len(record.CNAMEs) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(record.CNAMEs[len(record.CNAMEs)-1]), ".")
 }
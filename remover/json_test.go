@@ -0,0 +1,63 @@
+package remover
+
+import (
+	"github.com/antchfx/jsonquery"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const indentedHTTPXArray = `[
+  {
+    "input": "www.example.com",
+    "host": "10.0.0.1",
+    "url": "https://www.example.com",
+    "status_code": 200
+  },
+  {
+    "input": "a.example.com",
+    "host": "10.0.0.1",
+    "url": "https://a.example.com",
+    "status_code": 200
+  }
+]
+`
+
+func TestIndentedJSONArrayIsRead(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "httpx.json")
+	if err := os.WriteFile(filename, []byte(indentedHTTPXArray), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	records := LoadHTTPXRecords([]string{filename}, "newest")
+	if len(records) != 2 {
+		t.Errorf("expected two HTTPX records, got %d", len(records))
+	}
+	entries, err := jsonquery.QueryAll(GetDocumentFromFile(filename), "/*")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected two document entries, got %d (%v)", len(entries), err)
+	}
+	entries, err = jsonquery.QueryAll(GetDocumentFromFiles([]string{filename, filename}), "/*")
+	if err != nil || len(entries) != 4 {
+		t.Errorf("expected four document entries, got %d (%v)", len(entries), err)
+	}
+}
+
+func TestGetDNSRecordsForIPAddressMatchesExactly(t *testing.T) {
+	records := []DNSRecord{
+		{Host: "a.example.com", IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "b.example.com", IPv4Addresses: []string{"1.2.3.40"}},
+		{Host: "c.example.com", IPv4Addresses: []string{"5.6.7.8", "1.2.3.4"}},
+		{Host: "d.example.com", IPv6Addresses: []string{"2001:db8::1"}},
+	}
+	hosts := GetDNSRecordsForIPAddress(records, "1.2.3.4")
+	if len(hosts) != 2 || hosts[0].Host != "a.example.com" || hosts[1].Host != "c.example.com" {
+		t.Errorf("expected a.example.com and c.example.com, got %+v", hosts)
+	}
+	if hosts := GetDNSRecordsForIPAddress(records, "2001:db8::1"); len(hosts) != 1 || hosts[0].Host != "d.example.com" {
+		t.Errorf("expected d.example.com, got %+v", hosts)
+	}
+	if hosts := GetDNSRecordsForIPAddress(records, "1.2.3.5"); len(hosts) != 0 {
+		t.Errorf("expected no records, got %+v", hosts)
+	}
+}
@@ -13,7 +13,7 @@ func (l *logger) Say(msg string) {
 	l.Info(msg)
 }
 func (l *logger) Sayf(fmt string, args ...interface{}) {
-	l.Infof(fmt, args)
+	l.Infof(fmt, args...)
 }
 func (l *logger) SayWithField(msg string, k string, v interface{}) {
 	l.WithField(k, v).Info(msg)
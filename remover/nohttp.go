@@ -0,0 +1,74 @@
+package remover
+
+import (
+	"sort"
+	"strings"
+)
+
+type NonHTTPHost struct {
+	Host           string
+	IPv4Addresses  []string
+	IPv6Addresses  []string
+	DuplicateHosts []string
+}
+
+/*
+Returns all hostnames which resolve but have no HTTP response in the HTTPX input (e.g. mail, VPN or SSH only hosts).
+Hostnames resolving to the same set of IP addresses are deduplicated, the best match is used as representative and
+the others are added as duplicate hosts.
+*/
+func (p *Remover) getNonHTTPHosts(dnsRecords []DNSRecord, httpxEntries []SimpleHTTPXEntry) []NonHTTPHost {
+	httpHosts := make(map[string]bool)
+	for _, entry := range httpxEntries {
+		host, _ := getHostAndPort(entry.Input)
+		httpHosts[host] = true
+	}
+
+	recordsPerIPSet := make(map[string][]DNSRecord)
+	for _, record := range dnsRecords {
+		if httpHosts[record.Host] || (len(record.IPv4Addresses) == 0 && len(record.IPv6Addresses) == 0) {
+			continue
+		}
+		if checkIfHostStringIsContained(record.Host, unwantedHosts, "") {
+			continue
+		}
+		addresses := append(append([]string{}, record.IPv4Addresses...), record.IPv6Addresses...)
+		sort.Strings(addresses)
+		key := strings.Join(addresses, ",")
+		recordsPerIPSet[key] = append(recordsPerIPSet[key], record)
+	}
+
+	var nonHTTPHosts []NonHTTPHost
+	for _, records := range recordsPerIPSet {
+		var candidates []SimpleHTTPXEntry
+		for _, record := range records {
+			candidates = append(candidates, SimpleHTTPXEntry{Input: record.Host})
+		}
+		representative := records[0]
+		if len(candidates) > 1 {
+			bestMatch := getBestDuplicateMatch(candidates, p.options.Project, make(map[string]SimpleHTTPXEntry))
+			for _, record := range records {
+				if record.Host == bestMatch.Input {
+					representative = record
+				}
+			}
+		}
+		nonHTTPHost := NonHTTPHost{
+			Host:           representative.Host,
+			IPv4Addresses:  representative.IPv4Addresses,
+			IPv6Addresses:  representative.IPv6Addresses,
+			DuplicateHosts: []string{},
+		}
+		for _, record := range records {
+			if record.Host != representative.Host {
+				nonHTTPHost.DuplicateHosts = AppendIfMissing(nonHTTPHost.DuplicateHosts, record.Host)
+			}
+		}
+		sort.Strings(nonHTTPHost.DuplicateHosts)
+		nonHTTPHosts = append(nonHTTPHosts, nonHTTPHost)
+	}
+	sort.Slice(nonHTTPHosts, func(i, j int) bool {
+		return nonHTTPHosts[i].Host < nonHTTPHosts[j].Host
+	})
+	return nonHTTPHosts
+}
@@ -0,0 +1,30 @@
+package remover
+
+import (
+	"testing"
+)
+
+func TestGetNonHTTPHosts(t *testing.T) {
+	records := []DNSRecord{
+		{Host: "www.example.com", IPv4Addresses: []string{"1.1.1.1"}},
+		{Host: "a.vpn.example.com", IPv4Addresses: []string{"2.2.2.2", "1.1.1.1"}},
+		{Host: "vpn.example.com", IPv4Addresses: []string{"1.1.1.1", "2.2.2.2"}},
+		{Host: "ssh.example.com", IPv4Addresses: []string{"1.1.1.1"}},
+		{Host: "autodiscover.example.com", IPv4Addresses: []string{"3.3.3.3"}},
+		{Host: "dangling.example.com", CNAMEs: []string{"gone.saas.net"}},
+	}
+	httpxEntries := []SimpleHTTPXEntry{{Input: "www.example.com:443", Host: "1.1.1.1"}}
+	p := &Remover{options: &Options{Project: "example.com"}}
+
+	nonHTTPHosts := p.getNonHTTPHosts(records, httpxEntries)
+
+	if len(nonHTTPHosts) != 2 {
+		t.Fatalf("expected two hosts without HTTP, got %+v", nonHTTPHosts)
+	}
+	if nonHTTPHosts[0].Host != "ssh.example.com" || len(nonHTTPHosts[0].DuplicateHosts) != 0 {
+		t.Errorf("expected ssh.example.com without duplicates, got %+v", nonHTTPHosts[0])
+	}
+	if nonHTTPHosts[1].Host != "vpn.example.com" || len(nonHTTPHosts[1].DuplicateHosts) != 1 || nonHTTPHosts[1].DuplicateHosts[0] != "a.vpn.example.com" {
+		t.Errorf("expected vpn.example.com with a.vpn.example.com as duplicate, got %+v", nonHTTPHosts[1])
+	}
+}
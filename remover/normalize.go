@@ -0,0 +1,114 @@
+package remover
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	whitespaceRegex      = regexp.MustCompile(`\s+`)
+	scriptRegex          = regexp.MustCompile(`(?is)<script\b[^>]*>.*?</script\s*>`)
+	styleRegex           = regexp.MustCompile(`(?is)<style\b[^>]*>.*?</style\s*>`)
+	normalizationRules   []normalizationRule
+	hostnameRegexes      map[string]*regexp.Regexp
+	hostnameRegexesMutex sync.Mutex
+	useNormalizedHash    bool
+	defaultNormalization = NormalizationConfig{
+		MaskHostname:       true,
+		CollapseWhitespace: true,
+		Replacements: []Replacement{
+			{Pattern: `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`, Replacement: "{timestamp}"},
+			{Pattern: `(?i)(nonce|csrf[_-]?token|authenticity_token|__RequestVerificationToken)(["'=:\s]+)[A-Za-z0-9+/=_-]{8,}`, Replacement: "$1$2{token}"},
+			{Pattern: `(?i)(jsessionid|phpsessid|sessionid|sid)=[A-Za-z0-9_-]{8,}`, Replacement: "$1={session}"},
+		},
+	}
+)
+
+type normalizationRule struct {
+	regex       *regexp.Regexp
+	replacement string
+}
+
+/*
+Compiles the configured replacement rules. Must be called after the configuration has been loaded, an invalid
+pattern stops the application.
+*/
+func initializeNormalization(config NormalizationConfig) {
+	normalizationRules = []normalizationRule{}
+	hostnameRegexes = make(map[string]*regexp.Regexp)
+	for _, replacement := range config.Replacements {
+		regex, err := regexp.Compile(replacement.Pattern)
+		if err != nil {
+			log.Fatalf("Invalid normalization pattern %s: %v", replacement.Pattern, err)
+		}
+		normalizationRules = append(normalizationRules, normalizationRule{regex: regex, replacement: replacement.Replacement})
+	}
+}
+
+/*
+Normalizes a response body before it is compared with other responses. Depending on the configuration, script and
+style elements are stripped, the replacement rules are applied (timestamps, nonces, tokens, session IDs), occurrences
+of the requested hostname are masked and whitespace is collapsed, since these differences don't change the content itself.
+*/
+func normalizeBody(body string, hostname string) string {
+	config := appConfig.Normalization
+	if config.StripScripts {
+		body = scriptRegex.ReplaceAllString(body, "")
+	}
+	if config.StripStyles {
+		body = styleRegex.ReplaceAllString(body, "")
+	}
+	for _, rule := range normalizationRules {
+		body = rule.regex.ReplaceAllString(body, rule.replacement)
+	}
+	if config.MaskHostname && hostname != "" {
+		body = getHostnameRegex(hostname).ReplaceAllString(body, "{hostname}")
+	}
+	if config.CollapseWhitespace {
+		body = strings.TrimSpace(whitespaceRegex.ReplaceAllString(body, " "))
+	}
+	return body
+}
+
+// The regex masking a hostname is compiled once per hostname, since every body of the host is normalized with it.
+func getHostnameRegex(hostname string) *regexp.Regexp {
+	hostnameRegexesMutex.Lock()
+	defer hostnameRegexesMutex.Unlock()
+	if hostnameRegexes == nil {
+		hostnameRegexes = make(map[string]*regexp.Regexp)
+	}
+	regex, ok := hostnameRegexes[hostname]
+	if !ok {
+		regex = regexp.MustCompile(`(?i)` + regexp.QuoteMeta(hostname))
+		hostnameRegexes[hostname] = regex
+	}
+	return regex
+}
+
+/*
+Selects the hash used for all HTTPX entries of the run. The hash of the normalized body is only used if every record
+contains the raw body, otherwise the HTTPX body hash is used for all entries, since hashes of different kinds never match.
+*/
+func initializeHashScheme(records []HTTPXRecord) {
+	useNormalizedHash = len(records) > 0
+	for _, record := range records {
+		if body, ok := record.Values["body"].(string); !ok || body == "" {
+			useNormalizedHash = false
+			break
+		}
+	}
+	if useNormalizedHash {
+		log.Infof("Using hashes of the normalized bodies")
+	} else {
+		log.Infof("Using the HTTPX body hashes, since not every entry contains the raw body")
+	}
+}
+
+// Creates the hash of the normalized body, which is used instead of the body hash provided by HTTPX.
+func getNormalizedBodyHash(body string, hostname string) string {
+	hash := sha256.Sum256([]byte(normalizeBody(body, hostname)))
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
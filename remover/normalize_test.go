@@ -0,0 +1,51 @@
+package remover
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizedBodiesOfDifferentHostsMatch(t *testing.T) {
+	appConfig.Normalization = defaultNormalization
+	appConfig.Normalization.StripScripts = true
+	initializeNormalization(appConfig.Normalization)
+	defer func() {
+		appConfig.Normalization = NormalizationConfig{}
+		initializeNormalization(appConfig.Normalization)
+	}()
+
+	first := "<html>\n  <script>var id = 1;</script>\n  <p>Welcome to a.example.com</p>\n  <p>Generated 2023-01-01T10:00:00Z</p>\n" +
+		"  <form csrf_token=\"abcdefgh12345678\">\n  <a href=\"/?PHPSESSID=aaaaaaaaaaaa\">Home</a>\n</html>"
+	second := "<html> <script>var id = 2;</script> <p>Welcome to B.EXAMPLE.COM</p> <p>Generated 2024-12-31 23:59:59.123+01:00</p> " +
+		"<form csrf_token=\"zyxwvuts87654321\"> <a href=\"/?PHPSESSID=bbbbbbbbbbbb\">Home</a> </html>"
+	if getNormalizedBodyHash(first, "a.example.com") != getNormalizedBodyHash(second, "b.example.com") {
+		t.Errorf("expected the same hash, got %q and %q", normalizeBody(first, "a.example.com"), normalizeBody(second, "b.example.com"))
+	}
+	if getNormalizedBodyHash(first, "a.example.com") == getNormalizedBodyHash("<html><p>Other content</p></html>", "a.example.com") {
+		t.Errorf("expected a different hash for different content")
+	}
+}
+
+func TestHashSchemeRequiresAllBodies(t *testing.T) {
+	withBodies := []HTTPXRecord{
+		newHTTPXRecord("a.example.com", "1.2.3.4", "<html>a</html>"),
+		newHTTPXRecord("b.example.com", "1.2.3.4", "<html>b</html>"),
+	}
+	initializeHashScheme(withBodies)
+	if !useNormalizedHash {
+		t.Errorf("expected the normalized hash to be used if all records contain the body")
+	}
+
+	withoutBody := newHTTPXRecord("c.example.com", "1.2.3.4", "")
+	withoutBody.Values["hash"] = map[string]interface{}{"body_mmh3": "111"}
+	initializeHashScheme(append(withBodies, withoutBody))
+	if useNormalizedHash {
+		t.Errorf("expected the HTTPX body hash to be used if a record doesn't contain the body")
+	}
+	entries := GetAllHTTPXEntries(GetDocumentFromHTTPXRecords(append(withBodies, withoutBody)))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.BodyHash, "sha256:") {
+			t.Errorf("expected no normalized hash for %s, got %s", entry.Input, entry.BodyHash)
+		}
+	}
+}
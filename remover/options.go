@@ -6,8 +6,10 @@ import (
 	"github.com/projectdiscovery/goflags"
 	folderutil "github.com/projectdiscovery/utils/folder"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 var (
@@ -15,16 +17,38 @@ var (
 )
 
 type Options struct {
-	SettingsFile string
-	Project      string
-	BaseFolder   string
-	Domains      bool
-	Email        bool
-	Ports        bool
-	Silent       bool
-	Version      bool
-	NoColor      bool
-	Verbose      bool
+	SettingsFile       string
+	Project            string
+	OwnedOnly          bool
+	MergePolicy        string
+	Compression        string
+	Format             string
+	BaseFolder         string
+	Domains            bool
+	Email              bool
+	Ports              bool
+	StatusGrouping     string
+	Scope              string
+	DNSPolicy          string
+	PreferSources      bool
+	TemplateCheck      bool
+	TechMatch          bool
+	HeaderMatch        bool
+	TLSMatch           bool
+	TLSCluster         bool
+	CNAMECluster       bool
+	ScreenshotCheck    bool
+	ScreenshotDistance int
+	WildcardCheck      bool
+	Verify             bool
+	VerifySample       int
+	Timeout            int
+	RateLimit          int
+	Concurrency        int
+	Silent             bool
+	Version            bool
+	NoColor            bool
+	Verbose            bool
 }
 
 // ParseOptions parses the command line flags provided by a user
@@ -36,12 +60,43 @@ func ParseOptions() *Options {
 
 	flagSet.CreateGroup("input", "Input",
 		flagSet.StringVarP(&options.Project, "project", "p", "", "project name for metadata addition"),
+		flagSet.BoolVarP(&options.OwnedOnly, "owned-only", "oo", false, "only process IPs owned by the configured organizations"),
+		flagSet.StringVarP(&options.MergePolicy, "merge-policy", "mp", "newest", "record used if multiple HTTPX inputs contain the same URL (newest, 2xx, first)"),
 	)
 
 	flagSet.CreateGroup("config", "Config",
 		flagSet.StringVar(&options.SettingsFile, "config", defaultSettingsLocation, "settings (Yaml) file location"),
 	)
 
+	flagSet.CreateGroup("output", "Output",
+		flagSet.StringVar(&options.Compression, "compress", "none", "compression of the JSON findings (none, gzip, zstd)"),
+		flagSet.StringVarP(&options.Format, "format", "f", "json", "format of the duplicates, clean domains and DNS findings (json, jsonl, csv, tsv)"),
+	)
+
+	flagSet.CreateGroup("matching", "Matching",
+		flagSet.StringVarP(&options.StatusGrouping, "status-grouping", "sg", "code", "group hosts by status before merging (code, class, none)"),
+		flagSet.StringVarP(&options.Scope, "scope", "s", "port", "scope in which hosts are merged (ip, port, port-scheme)"),
+		flagSet.StringVarP(&options.DNSPolicy, "dns-policy", "dp", "best", "hostnames used for IPs without HTTP responses (best, all)"),
+		flagSet.BoolVarP(&options.PreferSources, "prefer-sources", "ps", false, "prefer hosts confirmed by more enumeration sources as best match"),
+		flagSet.BoolVarP(&options.TechMatch, "tech-match", "tem", true, "only merge hosts with the same detected technologies (ignoring versions and order)"),
+		flagSet.BoolVarP(&options.HeaderMatch, "header-match", "hm", false, "only merge hosts with the same response header fingerprint"),
+		flagSet.BoolVarP(&options.TLSMatch, "tls-match", "tm", false, "only merge hosts with the same JARM and TLS fingerprint"),
+		flagSet.BoolVarP(&options.TLSCluster, "tls-cluster", "tcl", false, "merge hosts on different IPs with the same status, TLS endpoint and body hash"),
+		flagSet.BoolVarP(&options.CNAMECluster, "cname-cluster", "ccl", false, "merge hosts with the same final CNAME target (e.g. SaaS endpoint), status and body hash"),
+		flagSet.BoolVarP(&options.TemplateCheck, "template-check", "tc", false, "merge hosts with the same HTML structure as template duplicates (requires raw bodies)"),
+		flagSet.BoolVarP(&options.ScreenshotCheck, "screenshot-check", "sc", false, "merge hosts with visually identical screenshots"),
+		flagSet.IntVarP(&options.ScreenshotDistance, "screenshot-distance", "sd", 5, "maximum Hamming distance of screenshot hashes to be merged"),
+	)
+
+	flagSet.CreateGroup("active", "Active",
+		flagSet.BoolVarP(&options.WildcardCheck, "wildcard-check", "wc", false, "detect catch-all virtual hosts using requests with random hostnames"),
+		flagSet.BoolVar(&options.Verify, "verify", false, "re-fetch duplicates and split them if the responses don't match anymore"),
+		flagSet.IntVarP(&options.VerifySample, "verify-sample", "vs", 3, "number of duplicate hosts per entry to re-fetch during verification"),
+		flagSet.IntVar(&options.Timeout, "timeout", 10, "timeout in seconds for active requests"),
+		flagSet.IntVarP(&options.RateLimit, "rate-limit", "rl", 10, "maximum number of active requests per second (0 for unlimited)"),
+		flagSet.IntVarP(&options.Concurrency, "concurrency", "c", 2, "maximum number of concurrent active requests per host"),
+	)
+
 	flagSet.CreateGroup("debug", "Debug",
 		flagSet.BoolVar(&options.Silent, "silent", false, "show only results in output"),
 		flagSet.BoolVar(&options.Version, "version", false, "show version of the project"),
@@ -97,5 +152,41 @@ func (options *Options) validateOptions() error {
 		return errors.New("both verbose and silent mode specified")
 	}
 
+	if !slices.Contains([]string{"code", "class", "none"}, options.StatusGrouping) {
+		return errors.New("invalid status grouping " + options.StatusGrouping + " specified")
+	}
+
+	if !slices.Contains([]string{"newest", "2xx", "first"}, options.MergePolicy) {
+		return errors.New("invalid merge policy " + options.MergePolicy + " specified")
+	}
+
+	if !slices.Contains([]string{"none", "gzip", "zstd"}, options.Compression) {
+		return errors.New("invalid compression " + options.Compression + " specified")
+	}
+
+	if !slices.Contains([]string{"json", "jsonl", "csv", "tsv"}, options.Format) {
+		return errors.New("invalid format " + options.Format + " specified")
+	}
+
+	if !slices.Contains([]string{"ip", "port", "port-scheme"}, options.Scope) {
+		return errors.New("invalid scope " + options.Scope + " specified")
+	}
+
+	if !slices.Contains([]string{"best", "all"}, options.DNSPolicy) {
+		return errors.New("invalid DNS policy " + options.DNSPolicy + " specified")
+	}
+
+	if options.ScreenshotDistance < 0 || options.ScreenshotDistance > 64 {
+		return errors.New("screenshot distance must be between 0 and 64")
+	}
+
+	if options.Timeout <= 0 || options.Concurrency <= 0 || options.VerifySample <= 0 {
+		return errors.New("timeout, concurrency and verify sample must be greater than zero")
+	}
+
+	if options.RateLimit < 0 || options.RateLimit > int(time.Second) {
+		return errors.New("rate limit must be between 0 and 1000000000")
+	}
+
 	return nil
 }
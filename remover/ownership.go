@@ -0,0 +1,108 @@
+package remover
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	// Keywords of organizations providing cloud, CDN or hosting services
+	cdnOrganizations     = []string{"cloudflare", "akamai", "fastly", "incapsula", "imperva", "edgecast", "limelight", "stackpath", "cdn77", "bunny"}
+	cloudOrganizations   = []string{"amazon", "google", "microsoft", "oracle", "alibaba", "tencent", "ibm", "salesforce"}
+	hostingOrganizations = []string{"hetzner", "ovh", "digitalocean", "linode", "vultr", "contabo", "ionos", "1&1", "strato", "godaddy", "leaseweb", "scaleway", "world4you", "hostinger"}
+)
+
+type IPOwnership struct {
+	IP             string
+	ASN            int
+	Organization   string
+	Country        string
+	Classification string
+}
+
+/*
+Classifies the IP address by its ASN and organization. It is owned by the customer if the ASN or the organization is
+configured as owned, otherwise it is classified as cdn, cloud or hosting based on well known providers. If the IP is not
+contained in the database or no provider matches, it is classified as unknown.
+*/
+func classifyIPAddress(database *ASNDatabase, ipaddress string) IPOwnership {
+	ownership := IPOwnership{IP: ipaddress, Classification: "unknown"}
+	if database == nil {
+		return ownership
+	}
+	info, ok := database.Lookup(ipaddress)
+	if !ok {
+		return ownership
+	}
+	ownership.ASN = info.ASN
+	ownership.Organization = info.Organization
+	ownership.Country = info.Country
+
+	organization := strings.ToLower(info.Organization)
+	switch {
+	case isOwnedASN(info.ASN) || containsAnyKeyword(organization, appConfig.OwnedOrganizations):
+		ownership.Classification = "customer"
+	case containsAnyKeyword(organization, cdnOrganizations):
+		ownership.Classification = "cdn"
+	case containsAnyKeyword(organization, cloudOrganizations):
+		ownership.Classification = "cloud"
+	case containsAnyKeyword(organization, hostingOrganizations):
+		ownership.Classification = "hosting"
+	}
+	return ownership
+}
+
+func isOwnedASN(asn int) bool {
+	for _, owned := range appConfig.OwnedASNs {
+		if parseASN(owned) == asn {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyKeyword(value string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(value, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ownership IPOwnership) String() string {
+	return ownership.IP + " (AS" + strconv.Itoa(ownership.ASN) + " " + ownership.Organization + ", " + ownership.Classification + ")"
+}
+
+/*
+Only keeps the DNS records resolving to at least one of the IP addresses. Records without any address (e.g. dangling
+CNAMEs) are kept, since they don't point to IPs of other owners.
+*/
+func getDNSRecordsForIPAddresses(records []DNSRecord, ipaddresses []string) []DNSRecord {
+	var result []DNSRecord
+	for _, record := range records {
+		addresses := append(append([]string{}, record.IPv4Addresses...), record.IPv6Addresses...)
+		if len(addresses) == 0 {
+			result = append(result, record)
+			continue
+		}
+		for _, address := range addresses {
+			if ExistsInArray(ipaddresses, address) {
+				result = append(result, record)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Only keeps the HTTPX entries of the IP addresses.
+func getHTTPXEntriesForIPAddresses(entries []SimpleHTTPXEntry, ipaddresses []string) []SimpleHTTPXEntry {
+	var result []SimpleHTTPXEntry
+	for _, entry := range entries {
+		if ExistsInArray(ipaddresses, entry.Host) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
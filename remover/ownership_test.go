@@ -0,0 +1,24 @@
+package remover
+
+import (
+	"testing"
+)
+
+func TestOwnedOnlyFiltersDNSRecordsAndHTTPXEntries(t *testing.T) {
+	owned := []string{"1.1.1.1"}
+	records := []DNSRecord{
+		{Host: "owned.example.com", IPv4Addresses: []string{"2.2.2.2", "1.1.1.1"}},
+		{Host: "cdn.example.com", IPv4Addresses: []string{"2.2.2.2"}},
+		{Host: "dangling.example.com", CNAMEs: []string{"gone.saas.net"}},
+	}
+	filteredRecords := getDNSRecordsForIPAddresses(records, owned)
+	if len(filteredRecords) != 2 || filteredRecords[0].Host != "owned.example.com" || filteredRecords[1].Host != "dangling.example.com" {
+		t.Errorf("expected the records of owned IPs and without address, got %+v", filteredRecords)
+	}
+
+	entries := []SimpleHTTPXEntry{{Input: "owned.example.com", Host: "1.1.1.1"}, {Input: "cdn.example.com", Host: "2.2.2.2"}}
+	filteredEntries := getHTTPXEntriesForIPAddresses(entries, owned)
+	if len(filteredEntries) != 1 || filteredEntries[0].Input != "owned.example.com" {
+		t.Errorf("expected only the entry of the owned IP, got %+v", filteredEntries)
+	}
+}
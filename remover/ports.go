@@ -0,0 +1,260 @@
+package remover
+
+import (
+	"encoding/xml"
+	"github.com/antchfx/jsonquery"
+	"sort"
+	"strconv"
+)
+
+type OpenPort struct {
+	Port     int
+	Protocol string
+	Service  string `json:",omitempty"`
+	Product  string `json:",omitempty"`
+}
+
+type HostPorts struct {
+	Host         string
+	IP           string
+	NonHTTPPorts []OpenPort
+}
+
+type nmapRun struct {
+	Hosts []struct {
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name    string `xml:"name,attr"`
+				Product string `xml:"product,attr"`
+			} `xml:"service"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// Reads the open ports per IP address from an Nmap XML output.
+func GetOpenPortsFromNmapXML(filename string) map[string][]OpenPort {
+	portsPerIP := make(map[string][]OpenPort)
+	data, err := ReadFile(filename)
+	if err != nil {
+		log.Errorf("Reading Nmap XML input file failed: %s %s", err.Error(), filename)
+		return portsPerIP
+	}
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		log.Errorf("Parsing Nmap XML input file failed: %s %s", err.Error(), filename)
+		return portsPerIP
+	}
+	for _, host := range run.Hosts {
+		for _, address := range host.Addresses {
+			if address.AddrType != "ipv4" && address.AddrType != "ipv6" {
+				continue
+			}
+			for _, port := range host.Ports {
+				if port.State.State != "open" {
+					continue
+				}
+				portsPerIP[address.Addr] = appendPortIfMissing(portsPerIP[address.Addr], OpenPort{
+					Port:     port.PortID,
+					Protocol: port.Protocol,
+					Service:  port.Service.Name,
+					Product:  port.Service.Product,
+				})
+			}
+		}
+	}
+	return portsPerIP
+}
+
+/*
+Reads the open ports per IP address from the JSON(L) outputs of the port scans (ports_simple, naabu and masscan). Every
+entry provides the IP as ip or host and either a single port or a list of ports (numbers or objects as used by masscan).
+Hostnames provided by naabu are returned per IP address as well.
+*/
+func GetOpenPortsFromJSON(filename string) (map[string][]OpenPort, map[string][]string) {
+	portsPerIP := make(map[string][]OpenPort)
+	hostsPerIP := make(map[string][]string)
+	entries, err := jsonquery.QueryAll(GetDocumentFromFiles([]string{filename}), "/*")
+	if err != nil {
+		log.Errorf("Querying JSON error   #%v ", err)
+	}
+	for _, node := range entries {
+		entryValues, ok := node.Value().(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, _ := entryValues["host"].(string)
+		ipaddress, ok := entryValues["ip"].(string)
+		if !ok {
+			ipaddress = host
+		}
+		if ipaddress == "" {
+			continue
+		}
+		if host != "" && host != ipaddress {
+			hostsPerIP[ipaddress] = AppendIfMissing(hostsPerIP[ipaddress], host)
+		}
+		protocol, ok := entryValues["protocol"].(string)
+		if !ok {
+			protocol = "tcp"
+		}
+		var ports []interface{}
+		if values, ok := entryValues["ports"].([]interface{}); ok {
+			ports = values
+		} else if value, ok := entryValues["port"]; ok {
+			ports = []interface{}{value}
+		}
+		for _, value := range ports {
+			openPort := OpenPort{Port: getPortFromValue(value), Protocol: protocol}
+			if portValues, ok := value.(map[string]interface{}); ok {
+				if status, ok := portValues["status"].(string); ok && status != "open" {
+					continue
+				}
+				if proto, ok := portValues["proto"].(string); ok {
+					openPort.Protocol = proto
+				}
+			}
+			if openPort.Port > 0 {
+				portsPerIP[ipaddress] = appendPortIfMissing(portsPerIP[ipaddress], openPort)
+			}
+		}
+	}
+	return portsPerIP, hostsPerIP
+}
+
+func getPortFromValue(value interface{}) int {
+	switch port := value.(type) {
+	case float64:
+		return int(port)
+	case string:
+		number, _ := strconv.Atoi(port)
+		return number
+	case map[string]interface{}:
+		// Older naabu versions provide the port as object with Port
+		if value, ok := port["Port"]; ok {
+			return getPortFromValue(value)
+		}
+		return getPortFromValue(port["port"])
+	}
+	return 0
+}
+
+func appendPortIfMissing(ports []OpenPort, port OpenPort) []OpenPort {
+	for i, existing := range ports {
+		if existing.Port == port.Port && existing.Protocol == port.Protocol {
+			// Keep the service information if only one of the inputs provides it
+			if existing.Service == "" {
+				ports[i].Service = port.Service
+				ports[i].Product = port.Product
+			}
+			return ports
+		}
+	}
+	ports = append(ports, port)
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Port < ports[j].Port
+	})
+	return ports
+}
+
+// Returns the open ports which are not used by any HTTP service of the IP address in the HTTPX input.
+func getNonHTTPPorts(ports []OpenPort, httpxEntries []SimpleHTTPXEntry, ipaddress string) []OpenPort {
+	var nonHTTPPorts []OpenPort
+	for _, port := range ports {
+		isHTTP := false
+		for _, entry := range httpxEntries {
+			if entry.Host != ipaddress {
+				continue
+			}
+			if _, httpPort := getSchemeAndPort(entry); httpPort == strconv.Itoa(port.Port) && port.Protocol == "tcp" {
+				isHTTP = true
+				break
+			}
+		}
+		if !isHTTP {
+			nonHTTPPorts = append(nonHTTPPorts, port)
+		}
+	}
+	return nonHTTPPorts
+}
+
+func appendHostPortsIfMissing(slice []HostPorts, host string, ipaddress string, ports []OpenPort, httpxEntries []SimpleHTTPXEntry) []HostPorts {
+	for _, element := range slice {
+		if element.Host == host && element.IP == ipaddress {
+			return slice
+		}
+	}
+	return append(slice, HostPorts{Host: host, IP: ipaddress, NonHTTPPorts: getNonHTTPPorts(ports, httpxEntries, ipaddress)})
+}
+
+// Loads the open ports from all configured port scan outputs which exist.
+func (p *Remover) loadOpenPorts() (map[string][]OpenPort, map[string][]string) {
+	portsPerIP := make(map[string][]OpenPort)
+	hostsPerIP := make(map[string][]string)
+	if appConfig.PortsXMLFile != "" {
+		portsXMLFile := p.options.BaseFolder + "recon/" + appConfig.PortsXMLFile
+		if CheckIfFileExists(portsXMLFile, false) {
+			log.Infof("Using Nmap XML input %s", portsXMLFile)
+			for ipAddress, ports := range GetOpenPortsFromNmapXML(portsXMLFile) {
+				for _, port := range ports {
+					portsPerIP[ipAddress] = appendPortIfMissing(portsPerIP[ipAddress], port)
+				}
+			}
+		}
+	}
+	for _, portsFile := range []string{appConfig.PortsSimpleFile, appConfig.NaabuFile, appConfig.MasscanFile} {
+		if portsFile == "" {
+			continue
+		}
+		portsFile = p.options.BaseFolder + "recon/" + portsFile
+		if !CheckIfFileExists(portsFile, false) {
+			continue
+		}
+		log.Infof("Using ports input %s", portsFile)
+		ports, hosts := GetOpenPortsFromJSON(portsFile)
+		for ipAddress, ipPorts := range ports {
+			for _, port := range ipPorts {
+				portsPerIP[ipAddress] = appendPortIfMissing(portsPerIP[ipAddress], port)
+			}
+		}
+		for ipAddress, ipHosts := range hosts {
+			hostsPerIP[ipAddress] = AppendSliceIfMissing(hostsPerIP[ipAddress], ipHosts)
+		}
+	}
+	return portsPerIP, hostsPerIP
+}
+
+type IPPorts struct {
+	IP           string
+	Hosts        []string
+	NonHTTPPorts []OpenPort
+}
+
+/*
+Returns the open ports of every IP which have no HTTP data in the HTTPX input, together with all hostnames known for the
+IP (from DNS and the port scans). These are reported instead of being dropped.
+*/
+func getPortsWithoutHTTP(ipsInput []string, portsPerIP map[string][]OpenPort, hostsPerIP map[string][]string, dnsRecords []DNSRecord, httpxEntries []SimpleHTTPXEntry) []IPPorts {
+	var result []IPPorts
+	for _, ipAddress := range ipsInput {
+		nonHTTPPorts := getNonHTTPPorts(portsPerIP[ipAddress], httpxEntries, ipAddress)
+		if len(nonHTTPPorts) == 0 {
+			continue
+		}
+		hosts := append([]string{}, hostsPerIP[ipAddress]...)
+		for _, record := range GetDNSRecordsForIPAddress(dnsRecords, ipAddress) {
+			hosts = AppendIfMissing(hosts, record.Host)
+		}
+		sort.Strings(hosts)
+		result = append(result, IPPorts{IP: ipAddress, Hosts: hosts, NonHTTPPorts: nonHTTPPorts})
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package remover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const nmapXML = `<?xml version="1.0"?>
+<nmaprun>
+  <host>
+    <address addr="10.0.0.1" addrtype="ipv4"/>
+    <address addr="00:11:22:33:44:55" addrtype="mac"/>
+    <ports>
+      <port protocol="tcp" portid="22"><state state="open"/><service name="ssh" product="OpenSSH"/></port>
+      <port protocol="tcp" portid="25"><state state="closed"/><service name="smtp"/></port>
+      <port protocol="tcp" portid="443"><state state="open"/><service name="https"/></port>
+    </ports>
+  </host>
+</nmaprun>
+`
+
+const naabuJSONL = `{"host":"www.example.com","ip":"10.0.0.1","port":8443}
+{"ip":"10.0.0.2","port":{"Port":22,"Protocol":"tcp"}}
+`
+
+const masscanJSON = `[
+{"ip": "10.0.0.3", "timestamp": "1690000000", "ports": [ {"port": 3389, "proto": "tcp", "status": "open"} ] },
+{"ip": "10.0.0.3", "timestamp": "1690000000", "ports": [ {"port": 21, "proto": "tcp", "status": "closed"} ] }
+]
+`
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestGetOpenPortsFromNmapXML(t *testing.T) {
+	portsPerIP := GetOpenPortsFromNmapXML(writeTestFile(t, "ports.xml", nmapXML))
+	if len(portsPerIP) != 1 {
+		t.Fatalf("expected only the IP address to be used, got %+v", portsPerIP)
+	}
+	ports := portsPerIP["10.0.0.1"]
+	if len(ports) != 2 || ports[0].Port != 22 || ports[0].Product != "OpenSSH" || ports[1].Port != 443 {
+		t.Errorf("expected the open ports 22 and 443, got %+v", ports)
+	}
+}
+
+func TestGetOpenPortsFromNaabu(t *testing.T) {
+	portsPerIP, hostsPerIP := GetOpenPortsFromJSON(writeTestFile(t, "naabu.json", naabuJSONL))
+	if ports := portsPerIP["10.0.0.1"]; len(ports) != 1 || ports[0].Port != 8443 || ports[0].Protocol != "tcp" {
+		t.Errorf("expected port 8443 for 10.0.0.1, got %+v", ports)
+	}
+	if ports := portsPerIP["10.0.0.2"]; len(ports) != 1 || ports[0].Port != 22 {
+		t.Errorf("expected port 22 of the old naabu format for 10.0.0.2, got %+v", ports)
+	}
+	if hosts := hostsPerIP["10.0.0.1"]; len(hosts) != 1 || hosts[0] != "www.example.com" {
+		t.Errorf("expected www.example.com for 10.0.0.1, got %v", hosts)
+	}
+}
+
+func TestGetOpenPortsFromMasscan(t *testing.T) {
+	portsPerIP, _ := GetOpenPortsFromJSON(writeTestFile(t, "masscan.json", masscanJSON))
+	if ports := portsPerIP["10.0.0.3"]; len(ports) != 1 || ports[0].Port != 3389 {
+		t.Errorf("expected only the open port 3389, got %+v", ports)
+	}
+}
+
+func TestAppendPortIfMissingKeepsServiceAndOrder(t *testing.T) {
+	var ports []OpenPort
+	ports = appendPortIfMissing(ports, OpenPort{Port: 443, Protocol: "tcp"})
+	ports = appendPortIfMissing(ports, OpenPort{Port: 22, Protocol: "tcp"})
+	ports = appendPortIfMissing(ports, OpenPort{Port: 443, Protocol: "tcp", Service: "https", Product: "nginx"})
+	ports = appendPortIfMissing(ports, OpenPort{Port: 53, Protocol: "udp"})
+	if len(ports) != 3 || ports[0].Port != 22 || ports[1].Port != 53 || ports[2].Port != 443 {
+		t.Fatalf("expected the ports 22, 53 and 443 in order, got %+v", ports)
+	}
+	if ports[2].Service != "https" || ports[2].Product != "nginx" {
+		t.Errorf("expected the service information of the second input, got %+v", ports[2])
+	}
+}
+
+func TestGetPortsWithoutHTTP(t *testing.T) {
+	portsPerIP := map[string][]OpenPort{
+		"1.1.1.1": {{Port: 22, Protocol: "tcp"}, {Port: 443, Protocol: "tcp"}, {Port: 8443, Protocol: "tcp"}},
+		"2.2.2.2": {{Port: 443, Protocol: "tcp"}},
+	}
+	hostsPerIP := map[string][]string{"1.1.1.1": {"scan.example.com"}}
+	records := []DNSRecord{{Host: "www.example.com", IPv4Addresses: []string{"1.1.1.1"}}}
+	httpxEntries := []SimpleHTTPXEntry{
+		{Input: "www.example.com", Host: "1.1.1.1", URL: "https://www.example.com"},
+		{Input: "www.example.com:8443", Host: "2.2.2.2", URL: "https://www.example.com:8443"},
+		{Input: "a.example.com", Host: "2.2.2.2", URL: "https://a.example.com"},
+	}
+
+	result := getPortsWithoutHTTP([]string{"1.1.1.1", "2.2.2.2"}, portsPerIP, hostsPerIP, records, httpxEntries)
+
+	if len(result) != 1 || result[0].IP != "1.1.1.1" {
+		t.Fatalf("expected only 1.1.1.1 to have ports without HTTP, got %+v", result)
+	}
+	if len(result[0].NonHTTPPorts) != 2 || result[0].NonHTTPPorts[0].Port != 22 || result[0].NonHTTPPorts[1].Port != 8443 {
+		t.Errorf("expected the ports 22 and 8443 without HTTP, got %+v", result[0].NonHTTPPorts)
+	}
+	if len(result[0].Hosts) != 2 || result[0].Hosts[0] != "scan.example.com" || result[0].Hosts[1] != "www.example.com" {
+		t.Errorf("expected the hosts from the port scan and DNS, got %v", result[0].Hosts)
+	}
+}
@@ -1,14 +1,19 @@
 package remover
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/antchfx/jsonquery"
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -38,41 +43,60 @@ func (p *Remover) initialize(configLocation string) {
 		p.options.BaseFolder = p.options.BaseFolder + "/"
 	}
 	appConfig.HttpxDomainsFile = strings.Replace(appConfig.HttpxDomainsFile, "{project_name}", p.options.Project, -1)
+	appConfig.HttpxIPsFile = strings.Replace(appConfig.HttpxIPsFile, "{project_name}", p.options.Project, -1)
+	appConfig.HttpxCleanFile = strings.Replace(appConfig.HttpxCleanFile, "{project_name}", p.options.Project, -1)
+	appConfig.HttpxDuplicatesFile = strings.Replace(appConfig.HttpxDuplicatesFile, "{project_name}", p.options.Project, -1)
 	appConfig.DpuxFile = strings.Replace(appConfig.DpuxFile, "{project_name}", p.options.Project, -1)
+	appConfig.DnsmxFile = strings.Replace(appConfig.DnsmxFile, "{project_name}", p.options.Project, -1)
+	appConfig.PortsXMLFile = strings.Replace(appConfig.PortsXMLFile, "{project_name}", p.options.Project, -1)
+	appConfig.PortsSimpleFile = strings.Replace(appConfig.PortsSimpleFile, "{project_name}", p.options.Project, -1)
+	appConfig.NaabuFile = strings.Replace(appConfig.NaabuFile, "{project_name}", p.options.Project, -1)
+	appConfig.MasscanFile = strings.Replace(appConfig.MasscanFile, "{project_name}", p.options.Project, -1)
+	appConfig.SubfinderFile = strings.Replace(appConfig.SubfinderFile, "{project_name}", p.options.Project, -1)
+	appConfig.AmassFile = strings.Replace(appConfig.AmassFile, "{project_name}", p.options.Project, -1)
+	preferConfirmedHosts = p.options.PreferSources
+	initializeNormalization(appConfig.Normalization)
+	client.Timeout = time.Duration(p.options.Timeout) * time.Second
+	if p.options.RateLimit > 0 {
+		p.rateLimiter = time.NewTicker(time.Second / time.Duration(p.options.RateLimit))
+	}
 }
 
 func loadConfigFrom(location string) Config {
-	var config Config
+	config := Config{Normalization: defaultNormalization}
 	var yamlFile []byte
 	var err error
 
 	yamlFile, err = os.ReadFile(location)
 	if err != nil {
-		yamlFile, err = os.ReadFile(defaultSettingsLocation)
+		location = defaultSettingsLocation
+		yamlFile, err = os.ReadFile(location)
 		if err != nil {
 			log.Fatalf("yamlFile.Get err   #%v ", err)
 		}
 	}
 
-	err = yaml.Unmarshal(yamlFile, &config)
-	if err != nil {
-		log.Fatalf("Unmarshal: %v", err)
+	// Unknown keys are rejected, otherwise misspelled or unsupported settings would be silently ignored.
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlFile))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&config)
+	if err != nil && err != io.EOF {
+		log.Fatalf("Invalid settings file %s: %v", location, err)
 	}
+	return config
+}
 
-	if &config == nil {
-		config = Config{
-			S2SPath:          "S://",
-			HttpxDomainsFile: "http_from.domains.output.json",
-			DpuxFile:         "dpux.{project_name}.output.json",
-			DpuxIPFile:       "dpux_clean.txt",
-		}
-	}
+// JSON findings are compressed if requested, therefore the extension depends on the compression.
+func (p *Remover) getFindingsFile(name string) string {
+	return p.options.BaseFolder + "findings/" + name + getCompressionExtension(p.options.Compression)
+}
 
-	err = yaml.Unmarshal(yamlFile, &config)
-	if err != nil {
-		log.Fatalf("Unmarshal: %v", err)
+// Files referenced in the settings are resolved relative to the settings file, if not absolute.
+func (p *Remover) getConfigFilePath(location string) string {
+	if filepath.IsAbs(location) {
+		return location
 	}
-	return config
+	return filepath.Join(filepath.Dir(p.options.SettingsFile), location)
 }
 
 //-------------------------------------------
@@ -90,24 +114,88 @@ func (p *Remover) Remove() error {
 }
 
 func (p *Remover) CleanDomains() {
-	// Get JSON file
-	httpxInputFile := p.options.BaseFolder + "recon/" + appConfig.HttpxDomainsFile
-	log.Infof("Using HTTPX domains input %s", httpxInputFile)
-	httpxInput := GetDocumentFromFile(httpxInputFile)
+	// Get JSON files, all HTTPX inputs are merged into one dataset
+	httpxRecords := LoadHTTPXRecords(p.getHTTPXInputFiles(), p.options.MergePolicy)
+	initializeHashScheme(httpxRecords)
+	httpxInput := GetDocumentFromHTTPXRecords(httpxRecords)
+	httpxEntries := GetAllHTTPXEntries(httpxInput)
 
 	ipsInputFile := p.options.BaseFolder + "recon/" + appConfig.DpuxIPFile
 	log.Infof("Using DPUx IP input %s", ipsInputFile)
 	ipsInput := ReadTxtFileLines(ipsInputFile)
 
+	p.loadHostSources()
+
+	// Get the open ports per IP from the port scans, if available. IPs only found by the port scans are used as well.
+	portsPerIP, hostsPerIP := p.loadOpenPorts()
+	// Sorted, so that the IPs are processed in the same order in every run
+	var portScanIPs []string
+	for ipAddress := range portsPerIP {
+		if !ExistsInArray(ipsInput, ipAddress) {
+			portScanIPs = append(portScanIPs, ipAddress)
+		}
+	}
+	sort.Strings(portScanIPs)
+	for _, ipAddress := range portScanIPs {
+		log.Debugf("Adding IP %s found by port scans", ipAddress)
+		ipsInput = append(ipsInput, ipAddress)
+	}
+
+	var asnDatabase *ASNDatabase
+	if appConfig.ASNDatabaseFile != "" {
+		asnDatabaseFile := p.getConfigFilePath(appConfig.ASNDatabaseFile)
+		if CheckIfFileExists(asnDatabaseFile, false) {
+			var err error
+			asnDatabase, err = LoadASNDatabase(asnDatabaseFile)
+			if err != nil {
+				log.Errorf("Loading ASN database failed: %s", err)
+			}
+		}
+	}
+
+	if p.options.OwnedOnly && asnDatabase == nil {
+		log.Fatalf("Processing only owned IPs requires an ASN database")
+	}
+
+	// Classify the IPs by their ownership and only use the ones owned by the customer if requested.
+	var ipOwnerships []IPOwnership
+	var ownedIPs []string
+	for _, ipAddress := range ipsInput {
+		ownership := classifyIPAddress(asnDatabase, ipAddress)
+		ipOwnerships = append(ipOwnerships, ownership)
+		if ownership.Classification == "customer" {
+			ownedIPs = append(ownedIPs, ipAddress)
+		} else if p.options.OwnedOnly {
+			log.Infof("Not using IP %s", ownership)
+		}
+	}
+	if p.options.OwnedOnly {
+		ipsInput = ownedIPs
+	}
+
 	dpuxInputFile := p.options.BaseFolder + "recon/" + appConfig.DpuxFile
 	log.Infof("Using DPUx input %s", dpuxInputFile)
 	dpuxInput := GetDocumentFromFile(dpuxInputFile)
+	allDNSRecords := GetAllDNSRecords(dpuxInput)
+	if appConfig.DnsmxFile != "" {
+		dnsmxInputFile := p.options.BaseFolder + "recon/" + appConfig.DnsmxFile
+		if CheckIfFileExists(dnsmxInputFile, false) {
+			log.Infof("Using DNSx MX input %s", dnsmxInputFile)
+			allDNSRecords = MergeDNSRecords(append(allDNSRecords, GetAllDNSRecords(GetDocumentFromFile(dnsmxInputFile))...))
+		}
+	}
+	// Hosts on IPs not owned by the customer must not show up in any output
+	if p.options.OwnedOnly {
+		allDNSRecords = getDNSRecordsForIPAddresses(allDNSRecords, ipsInput)
+		httpxEntries = getHTTPXEntriesForIPAddresses(httpxEntries, ipsInput)
+	}
 
 	// Get Hosts from DPUX, since not every ipAddress must have HTTP services enabled, they would not be found in
 
 	var nonDuplicateHosts []string
 	var duplicateHosts []Duplicates
 	var dnsRecords []DNSRecord
+	var hostsPorts []HostPorts
 	// Iterate over all hosts and resolve duplicates. Use the IP as selector.
 	// All identified IP addresses as resolved from DPUX are used.
 	for _, ipAddress := range ipsInput {
@@ -115,10 +203,16 @@ func (p *Remover) CleanDomains() {
 		cleanedHosts, duplicates := p.deduplicateByContent(httpxInput, ipAddress)
 		if len(cleanedHosts) > 0 {
 			for _, uniqueHost := range cleanedHosts {
+				host, _ := getHostAndPort(uniqueHost.Input)
+				// Entries of the HTTPX IP input are only used as evidence for the duplicates, they are no hosts
+				if isIPAddress(host) {
+					log.Debugf("Not adding IP address input %s to non duplicates", uniqueHost.Input)
+					continue
+				}
 				log.Debugf("Adding hostname %s to non duplicates", uniqueHost.Input)
 				nonDuplicateHosts = AppendIfMissing(nonDuplicateHosts, uniqueHost.Input)
-				host, _ := getHostAndPort(uniqueHost.Input)
-				dnsEntry := GetDNSRecordForHostname(dpuxInput, host)
+				hostsPorts = appendHostPortsIfMissing(hostsPorts, uniqueHost.Input, ipAddress, portsPerIP[ipAddress], httpxEntries)
+				dnsEntry := GetDNSRecordForHostname(allDNSRecords, host)
 				if dnsEntry.Host != "" {
 					dnsRecords = AppendDNSRecordIfMissing(dnsRecords, dnsEntry)
 				} else {
@@ -126,27 +220,74 @@ func (p *Remover) CleanDomains() {
 				}
 			}
 		} else {
-			dnsEntry := GetDNSRecordForIPAddress(dpuxInput, ipAddress)
-			if dnsEntry.Host != "" {
+			hostsForIP, duplicate := p.selectDNSRecordsForIPAddress(GetDNSRecordsForIPAddress(allDNSRecords, ipAddress), ipAddress)
+			for _, dnsEntry := range hostsForIP {
 				log.Debugf("Adding hostname %s to non duplicates for IP %s", dnsEntry.Host, ipAddress)
 				nonDuplicateHosts = AppendIfMissing(nonDuplicateHosts, dnsEntry.Host)
+				hostsPorts = appendHostPortsIfMissing(hostsPorts, dnsEntry.Host, ipAddress, portsPerIP[ipAddress], httpxEntries)
 				dnsRecords = AppendDNSRecordIfMissing(dnsRecords, dnsEntry)
-			} else {
-				log.Debugf("Found DNS record with empty ipAddress during processing IP %s", ipAddress)
 			}
+			if len(duplicate.DuplicateHosts) > 0 {
+				duplicates["dns|"+ipAddress] = duplicate
+			}
+		}
+		// Iterated in the order of the keys, so that the same representative is used in every run
+		duplicateKeys := make([]string, 0, len(duplicates))
+		for key := range duplicates {
+			duplicateKeys = append(duplicateKeys, key)
 		}
-		for _, duplicateEntry := range duplicates {
+		sort.Strings(duplicateKeys)
+		for _, key := range duplicateKeys {
+			duplicateEntry := duplicates[key]
+			// Representatives without any remaining duplicate are no cluster
+			if len(duplicateEntry.DuplicateHosts) == 0 {
+				continue
+			}
+			// The representative carries all open ports of its IP
+			duplicateEntry.Ports = portsPerIP[ipAddress]
 			duplicateHosts = AppendDuplicatesIfMissing(duplicateHosts, duplicateEntry)
 		}
 	}
 
+	// Representatives on different IPs are merged if they share the same service
+	if p.options.TLSCluster {
+		nonDuplicateHosts, duplicateHosts = p.mergeRepresentatives(nonDuplicateHosts, duplicateHosts, httpxEntries,
+			"tls", "same TLS endpoint", func(input string) string {
+				return p.getTLSClusterKey(getHTTPXEntryForInput(httpxEntries, input))
+			})
+	}
+	if p.options.CNAMECluster {
+		nonDuplicateHosts, duplicateHosts = p.mergeRepresentatives(nonDuplicateHosts, duplicateHosts, httpxEntries,
+			"cname", "same CNAME target", func(input string) string {
+				return p.getCNAMEClusterKey(getHTTPXEntryForInput(httpxEntries, input), allDNSRecords)
+			})
+	}
+	for index := range duplicateHosts {
+		// Representatives without HTTP response merged across IPs use the IP they resolve to
+		if duplicateHosts[index].IP == "" {
+			host, _ := getHostAndPort(duplicateHosts[index].Hostname)
+			if record := GetDNSRecordForHostname(allDNSRecords, host); len(record.IPv4Addresses) > 0 {
+				duplicateHosts[index].IP = record.IPv4Addresses[0]
+			}
+		}
+		if duplicateHosts[index].Ports == nil {
+			duplicateHosts[index].Ports = portsPerIP[duplicateHosts[index].IP]
+		}
+		duplicateHosts[index].Sources = getSourcesForDuplicate(duplicateHosts[index])
+		duplicateHosts[index].InputFiles = getInputFilesForDuplicate(duplicateHosts[index], httpxEntries)
+	}
+	hostsPorts = getHostsPortsForHosts(hostsPorts, nonDuplicateHosts)
+	dnsRecords = getDNSRecordsForHosts(dnsRecords, nonDuplicateHosts)
+
 	var cleanedDomains []string
 	var cleanedDomainsWithPorts []string
+	var cleanedInputs []string
 
 	for _, hostEntry := range nonDuplicateHosts {
 		host, port := getHostAndPort(hostEntry)
 
 		if !checkIfHostStringIsContained(host, unwantedHosts, "") {
+			cleanedInputs = append(cleanedInputs, hostEntry)
 			cleanedDomains = AppendIfMissing(cleanedDomains, host)
 			if port != "" {
 				cleanedDomainsWithPorts = AppendIfMissing(cleanedDomainsWithPorts, host+":"+port)
@@ -163,15 +304,110 @@ func (p *Remover) CleanDomains() {
 	cleanedDomainsString := ConvertStringArrayToString(cleanedDomains, "\n")
 	WriteToTextFileInProject(p.options.BaseFolder+"domains_clean.txt", cleanedDomainsString)
 
+	if len(hostSources) > 0 {
+		var cleanedDomainsSources []HostSources
+		for _, host := range cleanedDomains {
+			cleanedDomainsSources = append(cleanedDomainsSources, HostSources{Host: host, Sources: getSourcesForHost(host)})
+		}
+		data, _ := json.MarshalIndent(cleanedDomainsSources, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("domains_clean_sources.json"), string(data))
+	}
+
 	log.Infof("Found %d non duplicate hosts with port", len(cleanedDomainsWithPorts))
 	cleanedDomainsWithPortsString := ConvertStringArrayToString(cleanedDomainsWithPorts, "\n")
 	WriteToTextFileInProject(p.options.BaseFolder+"domains_clean_with_http_ports.txt", cleanedDomainsWithPortsString)
 
-	data, _ := json.MarshalIndent(duplicateHosts, "", " ")
-	WriteToTextFileInProject(p.options.BaseFolder+"findings/duplicates.json", string(data))
+	// Every cluster gets a stable ID, which is referenced in the tabular outputs
+	sortDuplicates(duplicateHosts)
+	writeResultRows(p, "domains_clean", getCleanHostRows(cleanedInputs, duplicateHosts, httpxEntries))
+
+	var data []byte
+	if p.options.Format == "json" {
+		data, _ = json.MarshalIndent(duplicateHosts, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("duplicates.json"), string(data))
+	} else {
+		writeResultRows(p, "duplicates", getDuplicateRows(duplicateHosts))
+	}
+
+	if asnDatabase != nil {
+		asnDatabase.EnrichDNSRecords(dnsRecords)
+	}
+
+	if p.options.Format == "json" {
+		data, _ = json.MarshalIndent(dnsRecords, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("dns_clean.json"), string(data))
+	} else {
+		writeResultRows(p, "dns_clean", getDNSRows(dnsRecords, duplicateHosts))
+	}
+
+	if asnDatabase != nil {
+		data, _ = json.MarshalIndent(ipOwnerships, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("ip_ownership.json"), string(data))
+	}
 
-	data, _ = json.MarshalIndent(dnsRecords, "", " ")
-	WriteToTextFileInProject(p.options.BaseFolder+"findings/dns_clean.json", string(data))
+	if len(portsPerIP) > 0 {
+		data, _ = json.MarshalIndent(hostsPorts, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("hosts_ports.json"), string(data))
+
+		portsWithoutHTTP := getPortsWithoutHTTP(ipsInput, portsPerIP, hostsPerIP, allDNSRecords, httpxEntries)
+		log.Infof("Found %d IPs with open ports without HTTP data", len(portsWithoutHTTP))
+		data, _ = json.MarshalIndent(portsWithoutHTTP, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("ports_without_http.json"), string(data))
+	}
+
+	// The original HTTPX entries are written for the representatives and optionally for the dropped duplicates
+	if appConfig.HttpxCleanFile != "" {
+		httpxCleanFile := p.options.BaseFolder + "recon/" + appConfig.HttpxCleanFile
+		written := WriteFilteredJSONLines(httpxRecords, httpxCleanFile, cleanedInputs)
+		log.Infof("Wrote %d HTTPX entries of non duplicate hosts to %s", written, httpxCleanFile)
+	}
+	if appConfig.HttpxDuplicatesFile != "" {
+		var droppedInputs []string
+		for _, duplicate := range duplicateHosts {
+			for _, duplicateHost := range duplicate.DuplicateHosts {
+				if !ExistsInArray(nonDuplicateHosts, duplicateHost) {
+					droppedInputs = AppendIfMissing(droppedInputs, duplicateHost)
+				}
+			}
+		}
+		httpxDuplicatesFile := p.options.BaseFolder + "recon/" + appConfig.HttpxDuplicatesFile
+		written := WriteFilteredJSONLines(httpxRecords, httpxDuplicatesFile, droppedInputs)
+		log.Infof("Wrote %d HTTPX entries of duplicate hosts to %s", written, httpxDuplicatesFile)
+	}
+
+	nonHTTPHosts := p.getNonHTTPHosts(allDNSRecords, httpxEntries)
+	var nonHTTPDomains []string
+	for _, nonHTTPHost := range nonHTTPHosts {
+		nonHTTPDomains = append(nonHTTPDomains, nonHTTPHost.Host)
+	}
+	log.Infof("Found %d hosts without HTTP service", len(nonHTTPDomains))
+	WriteToTextFileInProject(p.options.BaseFolder+"domains_no_http.txt", ConvertStringArrayToString(nonHTTPDomains, "\n"))
+	data, _ = json.MarshalIndent(nonHTTPHosts, "", " ")
+	WriteToTextFileInProject(p.getFindingsFile("domains_no_http.json"), string(data))
+
+	cnameClusters := GetCNAMEClusters(allDNSRecords)
+	if len(cnameClusters) > 0 {
+		log.Infof("Found %d CNAME targets shared by multiple hosts", len(cnameClusters))
+		data, _ = json.MarshalIndent(cnameClusters, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("cname_clusters.json"), string(data))
+	}
+
+	if appConfig.TakeoverFile != "" {
+		takeoverFile := p.getConfigFilePath(appConfig.TakeoverFile)
+		if CheckIfFileExists(takeoverFile, false) {
+			takeoverCandidates := GetTakeoverCandidates(allDNSRecords, httpxInput, loadTakeoverFingerprints(takeoverFile))
+			log.Infof("Found %d takeover candidates", len(takeoverCandidates))
+			data, _ = json.MarshalIndent(takeoverCandidates, "", " ")
+			WriteToTextFileInProject(p.getFindingsFile("takeover_candidates.json"), string(data))
+		}
+	}
+
+	tlsClusters := GetTLSClusters(httpxEntries)
+	if len(tlsClusters) > 0 {
+		log.Infof("Found %d TLS endpoints shared across IPs", len(tlsClusters))
+		data, _ = json.MarshalIndent(tlsClusters, "", " ")
+		WriteToTextFileInProject(p.getFindingsFile("tls_clusters.json"), string(data))
+	}
 
 	log.Info("Created cleaned domains file for project")
 
@@ -183,14 +419,232 @@ func (p *Remover) CleanDomains() {
 
 func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress string) ([]SimpleHTTPXEntry, map[string]Duplicates) {
 	hostsOnSameIP := GetHTTPXEntryForIPAddress(httpxInput, ipaddress)
-	cleanAfterHash := make(map[string]SimpleHTTPXEntry)
 	// TLDs are always used, even if they are duplicates
 	tlds := make(map[string]SimpleHTTPXEntry)
 	duplicates := make(map[string]Duplicates)
+	var combined []SimpleHTTPXEntry
+	if len(hostsOnSameIP) > 0 {
+		log.Debugf("Checking duplicates for IP %s", ipaddress)
+		if p.options.WildcardCheck {
+			var wildcardDuplicates map[string]Duplicates
+			hostsOnSameIP, wildcardDuplicates = p.detectWildcardHosts(ipaddress, hostsOnSameIP, tlds)
+			for key, duplicate := range wildcardDuplicates {
+				duplicates[key] = duplicate
+			}
+		}
+		// Entries are only compared to entries within the same group (e.g. same status code). Entries with a status
+		// which should never be merged are used as they are.
+		groups, unmerged := p.groupEntries(hostsOnSameIP)
+		combined = append(combined, unmerged...)
+		groupKeys := make([]string, 0, len(groups))
+		for groupKey := range groups {
+			groupKeys = append(groupKeys, groupKey)
+		}
+		sort.Strings(groupKeys)
+		for _, groupKey := range groupKeys {
+			cleanedEntries, groupDuplicates := p.deduplicateGroup(groups[groupKey], tlds)
+			if p.options.TemplateCheck {
+				var templateDuplicates map[string]Duplicates
+				cleanedEntries, templateDuplicates = p.deduplicateBySkeleton(cleanedEntries, tlds)
+				for key, duplicate := range templateDuplicates {
+					mergeCluster(groupDuplicates, key, duplicate)
+				}
+			}
+			if p.options.ScreenshotCheck {
+				var visualDuplicates map[string]Duplicates
+				cleanedEntries, visualDuplicates = p.deduplicateByScreenshot(cleanedEntries, tlds)
+				for key, duplicate := range visualDuplicates {
+					mergeCluster(groupDuplicates, key, duplicate)
+				}
+			}
+			combined = append(combined, cleanedEntries...)
+			for key, duplicate := range groupDuplicates {
+				duplicates[groupKey+"|"+key] = duplicate
+			}
+		}
+		if p.options.Verify {
+			// Hosts which don't match their duplicates anymore are deduplicated again using their fetched responses
+			split := p.verifyDuplicates(ipaddress, hostsOnSameIP, duplicates)
+			splitGroups, splitUnmerged := p.groupEntries(split)
+			combined = append(combined, splitUnmerged...)
+			splitKeys := make([]string, 0, len(splitGroups))
+			for groupKey := range splitGroups {
+				splitKeys = append(splitKeys, groupKey)
+			}
+			sort.Strings(splitKeys)
+			for _, groupKey := range splitKeys {
+				cleanedEntries, splitDuplicates := p.deduplicateGroup(splitGroups[groupKey], tlds)
+				combined = append(combined, cleanedEntries...)
+				for key, duplicate := range splitDuplicates {
+					duplicates["verified|"+groupKey+"|"+key] = duplicate
+				}
+			}
+		}
+	}
+	// Add the filtered list to nonduplicate ones.
+	for _, entry := range combined {
+		host, _ := getHostAndPort(entry.Input)
+		if len(tlds) > 0 {
+			delete(tlds, host)
+		}
+	}
+	found := false
+	for _, tld := range tlds {
+		for _, entry := range combined {
+			if tld.Input == entry.Input {
+				found = true
+				continue
+			}
+		}
+		if found == false {
+			combined = append(combined, tld)
+		}
+		found = false
+	}
+	return combined, duplicates
+
+}
+
+/*
+Selects the hostnames resolving to an IP address without HTTP responses which are used as non duplicates, depending on
+the configured DNS policy. Using "all" every hostname is used, using "best" only the best match is used and the other
+hostnames are returned as its duplicates.
+*/
+func (p *Remover) selectDNSRecordsForIPAddress(records []DNSRecord, ipaddress string) ([]DNSRecord, Duplicates) {
+	if len(records) <= 1 || p.options.DNSPolicy == "all" {
+		return records, Duplicates{}
+	}
+	var candidates []SimpleHTTPXEntry
+	for _, record := range records {
+		candidates = append(candidates, SimpleHTTPXEntry{Input: record.Host, Host: ipaddress})
+	}
+	bestMatch := getBestDuplicateMatch(candidates, p.options.Project, make(map[string]SimpleHTTPXEntry))
+	selected := records[0]
+	for _, record := range records {
+		if record.Host == bestMatch.Input {
+			selected = record
+		}
+	}
+	duplicate := Duplicates{
+		Hostname:       selected.Host,
+		IP:             ipaddress,
+		Reason:         "same IP without HTTP",
+		KeyType:        "dns",
+		Key:            ipaddress,
+		DuplicateHosts: []string{},
+	}
+	for _, record := range records {
+		if record.Host != selected.Host {
+			duplicate.DuplicateHosts = AppendIfMissing(duplicate.DuplicateHosts, record.Host)
+		}
+	}
+	return []DNSRecord{selected}, duplicate
+}
+
+/*
+Adds the cluster to the duplicates. Existing clusters headed by one of its hosts are folded into it, including their
+members and reason, since their representative is either the same or has become a duplicate itself.
+*/
+func mergeCluster(duplicates map[string]Duplicates, key string, cluster Duplicates) {
+	keys := make([]string, 0, len(duplicates))
+	for existingKey := range duplicates {
+		keys = append(keys, existingKey)
+	}
+	sort.Strings(keys)
+	for _, existingKey := range keys {
+		existing := duplicates[existingKey]
+		if existing.Hostname != cluster.Hostname && !ExistsInArray(cluster.DuplicateHosts, existing.Hostname) {
+			continue
+		}
+		cluster = foldCluster(cluster, existing)
+		delete(duplicates, existingKey)
+	}
+	duplicates[key] = cluster
+}
+
+// Adds the representative, members and reason of the existing cluster to the cluster.
+func foldCluster(cluster Duplicates, existing Duplicates) Duplicates {
+	for _, host := range append([]string{existing.Hostname}, existing.DuplicateHosts...) {
+		if host != cluster.Hostname {
+			cluster.DuplicateHosts = AppendIfMissing(cluster.DuplicateHosts, host)
+		}
+	}
+	if existing.Reason != "" && !strings.Contains(cluster.Reason, existing.Reason) {
+		if cluster.Reason == "" {
+			cluster.Reason = existing.Reason
+		} else {
+			cluster.Reason = existing.Reason + ", " + cluster.Reason
+		}
+	}
+	return cluster
+}
+
+/*
+Splits the entries of one IP into groups of entries which may be merged, using the key from getGroupKey. Entries with a
+status from the never merge list are returned separately, since they don't carry any content signal.
+*/
+func (p *Remover) groupEntries(entries []SimpleHTTPXEntry) (map[string][]SimpleHTTPXEntry, []SimpleHTTPXEntry) {
+	groups := make(map[string][]SimpleHTTPXEntry)
+	var unmerged []SimpleHTTPXEntry
+	for _, entry := range entries {
+		if slices.Contains(appConfig.NeverMergeStatus, entry.Status) {
+			log.Debugf("Not merging hostname %s with status %d", entry.Input, entry.Status)
+			unmerged = append(unmerged, entry)
+			continue
+		}
+		groupKey := p.getGroupKey(entry)
+		groups[groupKey] = append(groups[groupKey], entry)
+	}
+	return groups, unmerged
+}
+
+// The group key consists of the status (code or class), the port depending on the scope and the enabled fingerprints.
+func (p *Remover) getGroupKey(entry SimpleHTTPXEntry) string {
+	var parts []string
+	switch p.options.StatusGrouping {
+	case "none":
+	case "class":
+		parts = append(parts, strconv.Itoa(entry.Status/100)+"xx")
+	default:
+		parts = append(parts, strconv.Itoa(entry.Status))
+	}
+	switch p.options.Scope {
+	case "port":
+		_, port := getSchemeAndPort(entry)
+		parts = append(parts, port)
+	case "port-scheme":
+		scheme, port := getSchemeAndPort(entry)
+		parts = append(parts, scheme+"://"+port)
+	}
+	if p.options.TechMatch {
+		parts = append(parts, getTechnologyFingerprint(entry.Technologies))
+	}
+	if p.options.HeaderMatch {
+		parts = append(parts, entry.HeaderHash)
+	}
+	if p.options.TLSMatch {
+		parts = append(parts, entry.tlsFingerprint())
+	}
+	return strings.Join(parts, "|")
+}
+
+// Technologies are compared without their versions (e.g. "Nginx:1.19.0") and independent of their order.
+func getTechnologyFingerprint(technologies []string) string {
+	var names []string
+	for _, technology := range technologies {
+		name := strings.TrimSpace(strings.Split(technology, ":")[0])
+		names = AppendIfMissing(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (p *Remover) deduplicateGroup(hostsOnSameIP []SimpleHTTPXEntry, tlds map[string]SimpleHTTPXEntry) ([]SimpleHTTPXEntry, map[string]Duplicates) {
+	cleanAfterHash := make(map[string]SimpleHTTPXEntry)
+	duplicates := make(map[string]Duplicates)
 	cleanAfterWordsAndLines := make(map[string]SimpleHTTPXEntry)
 	if len(hostsOnSameIP) > 0 {
 		// Finding duplicates based on the hash values for the same IP.
-		log.Debugf("Checking duplicates for IP %s", ipaddress)
 		for _, hostEntry := range hostsOnSameIP {
 			log.Debugf("Checking hostname %s", hostEntry.Input)
 			if _, ok := cleanAfterHash[hostEntry.BodyHash]; !ok {
@@ -201,7 +655,7 @@ func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress str
 				possibleDupes := getSimpleEntriesForBodyHash(hostsOnSameIP, hostEntry.BodyHash)
 				if len(possibleDupes) > 1 {
 					bestMatch := getBestDuplicateMatch(possibleDupes, p.options.Project, tlds)
-					if (bestMatch != SimpleHTTPXEntry{}) {
+					if !bestMatch.isEmpty() {
 						cleanAfterHash[hostEntry.BodyHash] = bestMatch
 					} else {
 						cleanAfterHash[hostEntry.BodyHash] = hostEntry
@@ -232,7 +686,14 @@ func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress str
 		// for the same IP it is very likely that the content is the same although some minor thing changed
 		// and therefore the hash changed. (Used IP, hostname or some other changes such as generated Javascript)
 		// See austria-beteiligungen (hvw-wegraz.at), jaw.or.at for reasons.
-		for _, hostEntry := range cleanAfterHash {
+		// The entries are iterated in the order of their hashes, so that the same representative is used in every run.
+		hashes := make([]string, 0, len(cleanAfterHash))
+		for bodyHash := range cleanAfterHash {
+			hashes = append(hashes, bodyHash)
+		}
+		sort.Strings(hashes)
+		for _, bodyHash := range hashes {
+			hostEntry := cleanAfterHash[bodyHash]
 			key := strconv.Itoa(hostEntry.Words) + "-" + strconv.Itoa(hostEntry.Lines)
 			if len(cleanAfterHash) > 1 {
 				log.Debugf("Checking hostname %s", hostEntry.Input)
@@ -240,7 +701,7 @@ func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress str
 					possibleDupes := getSimpleEntriesForWordsAndLines(cleanAfterHash, hostEntry.Words, hostEntry.Lines)
 					if len(possibleDupes) > 1 {
 						bestMatch := getBestDuplicateMatch(possibleDupes, p.options.Project, tlds)
-						if (bestMatch != SimpleHTTPXEntry{}) {
+						if !bestMatch.isEmpty() {
 							// Use the best match
 							cleanAfterWordsAndLines[key] = bestMatch
 						} else {
@@ -266,19 +727,9 @@ func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress str
 						}
 						duplicates[key] = duplicate
 					} else {
-						//Only one entry exists, use it.
+						//Only one entry exists, use it. A duplicate for its body hash is kept as it is, since it was only
+						// merged by the hash.
 						cleanAfterWordsAndLines[key] = hostEntry
-						// Create the base entry for the duplicates. All duplicates of the words and lines are associated with this entry
-						duplicate := getDuplicate(cleanAfterWordsAndLines[key])
-						//If a duplicate for the body hash already exists, inline it to the new duplicates entry
-						if !reflect.DeepEqual(Duplicates{}, duplicates[duplicate.BodyHash]) {
-							if duplicate.Hostname != duplicates[duplicate.BodyHash].Hostname {
-								duplicate.DuplicateHosts = AppendIfMissing(duplicate.DuplicateHosts, duplicates[duplicate.BodyHash].Hostname)
-							}
-							duplicate.DuplicateHosts = AppendSliceIfMissingExcept(duplicate.DuplicateHosts, duplicates[duplicate.BodyHash].DuplicateHosts, duplicate.Hostname)
-							delete(duplicates, hostEntry.BodyHash)
-						}
-						duplicates[key] = duplicate
 					}
 				} else {
 					// All other are duplicates
@@ -305,37 +756,34 @@ func (p *Remover) deduplicateByContent(httpxInput *jsonquery.Node, ipaddress str
 			}
 		}
 	}
-	// Add the filtered list to nonduplicate ones.
-	var combined []SimpleHTTPXEntry
+	var cleanedEntries []SimpleHTTPXEntry
 	for _, entry := range cleanAfterWordsAndLines {
-		combined = append(combined, entry)
-		host, _ := getHostAndPort(entry.Input)
-		if len(tlds) > 0 {
-			delete(tlds, host)
-		}
+		cleanedEntries = append(cleanedEntries, entry)
 	}
-	found := false
-	for _, tld := range tlds {
-		for _, entry := range combined {
-			if tld.Input == entry.Input {
-				found = true
-				continue
-			}
-		}
-		if found == false {
-			combined = append(combined, tld)
+	sort.Slice(cleanedEntries, func(i, j int) bool {
+		return cleanedEntries[i].Input < cleanedEntries[j].Input
+	})
+	// The key (and reason) of the remaining duplicates is either the body hash or the words and lines
+	for key, duplicate := range duplicates {
+		duplicate.Key = key
+		if key == duplicate.BodyHash {
+			duplicate.KeyType = "hash"
+			duplicate.Reason = "identical body hash"
+		} else {
+			duplicate.KeyType = "words-lines"
+			duplicate.Reason = "same words and lines"
 		}
-		found = false
+		duplicates[key] = duplicate
 	}
-	return combined, duplicates
-
+	return cleanedEntries, duplicates
 }
 
 /*
 Finds the best match for different hostnames which result in the same hash value for the response, thus having the same
 content. The TLD of the project or in general is a TLD it is the preferred best duplicate match. Otherwise, the first
-matching from a list of preferred ones is used. If none has matched the last one which is checked is used.
-Currently it is not differentiated between ports.
+matching from a list of preferred ones is used. If none has matched the last one which is checked is used. If
+preferred, the subdomain confirmed by the most enumeration sources is used.
+Ports are not differentiated here, entries on different ports are only passed together if the scope is ip.
 Project: example.com
 Duplicates: example.com (1), example.at (2), test.example.com, www.example.com (3), sub.example.com (4)
 */
@@ -345,6 +793,16 @@ func getBestDuplicateMatch(entries []SimpleHTTPXEntry, project string, tlds map[
 	var possibleBestMatch SimpleHTTPXEntry
 	var host string
 	var port string
+	// IP address inputs are only used as best match if there is no hostname
+	var hostEntries []SimpleHTTPXEntry
+	for _, entry := range entries {
+		if host, _ = getHostAndPort(entry.Input); !isIPAddress(host) {
+			hostEntries = append(hostEntries, entry)
+		}
+	}
+	if len(hostEntries) > 0 {
+		entries = hostEntries
+	}
 	for _, entry := range entries {
 		host, port = getHostAndPort(entry.Input)
 		tld := ExtractDomainAndTldFromString(host)
@@ -352,7 +810,7 @@ func getBestDuplicateMatch(entries []SimpleHTTPXEntry, project string, tlds map[
 		// If not we use it as possible best match if it is an entry with port 443. If not we use it as general
 		if tld == host {
 			if tld == project {
-				if (currentBestMatch != SimpleHTTPXEntry{}) {
+				if !currentBestMatch.isEmpty() {
 					cbmHost, _ := getHostAndPort(currentBestMatch.Input)
 					if _, ok := tlds[cbmHost]; !ok {
 						tlds[cbmHost] = currentBestMatch
@@ -360,38 +818,41 @@ func getBestDuplicateMatch(entries []SimpleHTTPXEntry, project string, tlds map[
 				}
 				if port == "443" {
 					currentBestMatch = entry
-				} else if (currentBestMatch == SimpleHTTPXEntry{}) {
+				} else if currentBestMatch.isEmpty() {
 					currentBestMatch = entry
 				}
 
 			} else {
-				if port == "443" && (currentBestMatch == SimpleHTTPXEntry{}) {
+				if port == "443" && currentBestMatch.isEmpty() {
 					currentBestMatch = entry
 				} else {
 					if _, ok := tlds[host]; !ok {
 						tlds[host] = entry
 					}
-					if (possibleBestMatch == SimpleHTTPXEntry{}) {
+					if possibleBestMatch.isEmpty() {
 						possibleBestMatch = entry
 					}
 				}
 				log.Debugf("Added non duplicate entry: %s", entry.Input)
 			}
-		} else if (match == SimpleHTTPXEntry{}) {
+		} else if match.isEmpty() {
 			match = entry
-		} else if (match != SimpleHTTPXEntry{}) {
+		} else if !match.isEmpty() {
 			if checkIfHostStringIsContained(entry.Input, wantedHosts, tld) {
 				match = entry
 			}
 			if subDomainCount(match.Input) > subDomainCount(entry.Input) {
 				match = entry
 			}
+			if preferConfirmedHosts && len(getSourcesForHost(entry.Input)) > len(getSourcesForHost(match.Input)) {
+				match = entry
+			}
 		}
 	}
 
-	if (currentBestMatch != SimpleHTTPXEntry{}) {
+	if !currentBestMatch.isEmpty() {
 		match = currentBestMatch
-	} else if (possibleBestMatch != SimpleHTTPXEntry{}) {
+	} else if !possibleBestMatch.isEmpty() {
 		match = possibleBestMatch
 	}
 	// Remove the match from TLDs if it exists
@@ -419,6 +880,10 @@ func getSimpleEntriesForWordsAndLines(entries map[string]SimpleHTTPXEntry, words
 			filteredEntries = append(filteredEntries, entry)
 		}
 	}
+	// Sorted, since the best match depends on the order of the entries
+	sort.Slice(filteredEntries, func(i, j int) bool {
+		return filteredEntries[i].Input < filteredEntries[j].Input
+	})
 	return filteredEntries
 }
 
@@ -0,0 +1,118 @@
+package remover
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newHTTPXRecord(input string, ipaddress string, body string) HTTPXRecord {
+	words, lines := countWordsAndLines(body)
+	return HTTPXRecord{File: "httpx.json", Values: map[string]interface{}{
+		"input":       input,
+		"host":        ipaddress,
+		"url":         "https://" + input,
+		"status_code": float64(200),
+		"words":       float64(words),
+		"lines":       float64(lines),
+		"body":        body,
+	}}
+}
+
+func TestTemplateClusterIsMergedIntoHashCluster(t *testing.T) {
+	template := "<html><head><title>%s</title></head><body><div><h1>%s</h1><p>%s</p><ul><li>a</li><li>b</li></ul></div></body></html>"
+	records := []HTTPXRecord{
+		newHTTPXRecord("a.example.com", "1.2.3.4", fmt.Sprintf(template, "Shop", "Welcome", "Our shop")),
+		newHTTPXRecord("a1.example.com", "1.2.3.4", fmt.Sprintf(template, "Shop", "Welcome", "Our shop")),
+		newHTTPXRecord("b.example.com", "1.2.3.4", fmt.Sprintf(template, "Other tenant", "Hello there", "A completely different text")),
+	}
+	initializeHashScheme(records)
+	p := &Remover{options: &Options{Project: "example.com", Scope: "port", TemplateCheck: true}}
+
+	cleaned, duplicates := p.deduplicateByContent(GetDocumentFromHTTPXRecords(records), "1.2.3.4")
+
+	if len(cleaned) != 1 || cleaned[0].Input != "a.example.com" {
+		t.Fatalf("expected only a.example.com to remain, got %+v", cleaned)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected one cluster, got %+v", duplicates)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.Hostname != "a.example.com" || len(duplicate.DuplicateHosts) != 2 {
+			t.Errorf("expected a.example.com with a1 and b as duplicates, got %+v", duplicate)
+		}
+	}
+}
+
+func TestHashClusterKeepsItsKeyType(t *testing.T) {
+	records := []HTTPXRecord{
+		newHTTPXRecord("a.example.com", "1.2.3.4", "<html><body>Our shop</body></html>"),
+		newHTTPXRecord("a1.example.com", "1.2.3.4", "<html><body>Our shop</body></html>"),
+		newHTTPXRecord("b.example.com", "1.2.3.4", "<html><body>A completely different text\n</body></html>"),
+	}
+	initializeHashScheme(records)
+	p := &Remover{options: &Options{Project: "example.com", Scope: "port"}}
+
+	_, duplicates := p.deduplicateByContent(GetDocumentFromHTTPXRecords(records), "1.2.3.4")
+
+	if len(duplicates) != 1 {
+		t.Fatalf("expected one cluster, got %+v", duplicates)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.KeyType != "hash" || duplicate.Reason != "identical body hash" || duplicate.Key != duplicate.BodyHash {
+			t.Errorf("expected a hash cluster, got %+v", duplicate)
+		}
+	}
+}
+
+func TestIPAddressInputIsNoRepresentative(t *testing.T) {
+	records := []HTTPXRecord{
+		newHTTPXRecord("1.2.3.4", "1.2.3.4", "<html><body>Our shop</body></html>"),
+		newHTTPXRecord("www.shop.example.com", "1.2.3.4", "<html><body>Our shop</body></html>"),
+	}
+	initializeHashScheme(records)
+	p := &Remover{options: &Options{Project: "example.com", Scope: "port"}}
+
+	cleaned, duplicates := p.deduplicateByContent(GetDocumentFromHTTPXRecords(records), "1.2.3.4")
+
+	if len(cleaned) != 1 || cleaned[0].Input != "www.shop.example.com" {
+		t.Fatalf("expected only www.shop.example.com to remain, got %+v", cleaned)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.Hostname != "www.shop.example.com" || len(duplicate.DuplicateHosts) != 1 || duplicate.DuplicateHosts[0] != "1.2.3.4" {
+			t.Errorf("expected the IP address as duplicate of www.shop.example.com, got %+v", duplicate)
+		}
+	}
+}
+
+func TestGroupKeyIgnoresTechnologyVersionsAndOrder(t *testing.T) {
+	p := &Remover{options: &Options{StatusGrouping: "code", Scope: "ip", TechMatch: true}}
+	nginx := p.getGroupKey(SimpleHTTPXEntry{Status: 200, Technologies: []string{"Nginx:1.19.0", "PHP"}})
+	if key := p.getGroupKey(SimpleHTTPXEntry{Status: 200, Technologies: []string{"PHP", "Nginx:1.25.3"}}); key != nginx {
+		t.Errorf("expected the same group for other versions and order, got %s and %s", nginx, key)
+	}
+	if key := p.getGroupKey(SimpleHTTPXEntry{Status: 200, Technologies: []string{"Apache"}}); key == nginx {
+		t.Errorf("expected different groups for different technologies, got %s", key)
+	}
+}
+
+func TestSelectDNSRecordsForIPAddress(t *testing.T) {
+	records := []DNSRecord{
+		{Host: "mx.example.com", IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "example.com", IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "a.b.example.com", IPv4Addresses: []string{"1.2.3.4"}},
+	}
+
+	p := &Remover{options: &Options{Project: "example.com", DNSPolicy: "all"}}
+	if selected, duplicate := p.selectDNSRecordsForIPAddress(records, "1.2.3.4"); len(selected) != 3 || len(duplicate.DuplicateHosts) != 0 {
+		t.Errorf("expected all hostnames without duplicates, got %+v and %+v", selected, duplicate)
+	}
+
+	p.options.DNSPolicy = "best"
+	selected, duplicate := p.selectDNSRecordsForIPAddress(records, "1.2.3.4")
+	if len(selected) != 1 || selected[0].Host != "example.com" {
+		t.Fatalf("expected only the project domain, got %+v", selected)
+	}
+	if duplicate.Hostname != "example.com" || duplicate.KeyType != "dns" || len(duplicate.DuplicateHosts) != 2 {
+		t.Errorf("expected the other hostnames as duplicates of example.com, got %+v", duplicate)
+	}
+}
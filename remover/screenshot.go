@@ -0,0 +1,166 @@
+package remover
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var screenshotExtensions = []string{".png", ".jpeg", ".jpg"}
+
+/*
+Merges hosts whose screenshots look the same. Visually identical landing pages often have different markup, therefore
+a perceptual hash (difference hash) of the screenshot is compared. Hosts are merged if the Hamming distance of the
+hashes is within the configured threshold. Entries without a screenshot are returned as they are.
+*/
+func (p *Remover) deduplicateByScreenshot(entries []SimpleHTTPXEntry, tlds map[string]SimpleHTTPXEntry) ([]SimpleHTTPXEntry, map[string]Duplicates) {
+	duplicates := make(map[string]Duplicates)
+	var remaining []SimpleHTTPXEntry
+	var clusters [][]SimpleHTTPXEntry
+	var clusterHashes []uint64
+	for _, entry := range entries {
+		screenshot := p.getScreenshotPath(entry)
+		if screenshot == "" {
+			remaining = append(remaining, entry)
+			continue
+		}
+		hash, err := getPerceptualHashFromFile(screenshot)
+		if err != nil {
+			log.Debugf("Could not hash screenshot %s: %s", screenshot, err)
+			remaining = append(remaining, entry)
+			continue
+		}
+		found := false
+		for i, clusterHash := range clusterHashes {
+			if bits.OnesCount64(hash^clusterHash) <= p.options.ScreenshotDistance {
+				clusters[i] = append(clusters[i], entry)
+				found = true
+				break
+			}
+		}
+		if !found {
+			clusters = append(clusters, []SimpleHTTPXEntry{entry})
+			clusterHashes = append(clusterHashes, hash)
+		}
+	}
+	for i, clusterEntries := range clusters {
+		if len(clusterEntries) == 1 {
+			remaining = append(remaining, clusterEntries[0])
+			continue
+		}
+		representative := getBestDuplicateMatch(clusterEntries, p.options.Project, tlds)
+		if representative.isEmpty() {
+			representative = clusterEntries[0]
+		}
+		remaining = append(remaining, representative)
+		hash := fmt.Sprintf("%016x", clusterHashes[i])
+		duplicate := getDuplicate(representative)
+		duplicate.Reason = "visual duplicate"
+		duplicate.KeyType = "screenshot"
+		duplicate.Key = hash
+		duplicate.Evidence = "screenshot perceptual hash " + hash
+		for _, entry := range clusterEntries {
+			if entry.Input != representative.Input {
+				duplicate.DuplicateHosts = AppendIfMissing(duplicate.DuplicateHosts, entry.Input)
+			}
+		}
+		duplicates["screenshot-"+hash] = duplicate
+	}
+	return remaining, duplicates
+}
+
+/*
+Returns the location of the screenshot of the entry. The screenshot_path provided by HTTPX is used if it exists,
+otherwise the configured screenshot folder is searched for a file named after the URL (as gowitness does, e.g.
+https-www.example.com-443.png). Relative paths are resolved within the project folder.
+*/
+func (p *Remover) getScreenshotPath(entry SimpleHTTPXEntry) string {
+	if entry.ScreenshotPath != "" {
+		screenshot := entry.ScreenshotPath
+		if !filepath.IsAbs(screenshot) {
+			screenshot = p.options.BaseFolder + screenshot
+		}
+		if _, err := os.Stat(screenshot); err == nil {
+			return screenshot
+		}
+	}
+	if appConfig.ScreenshotFolder == "" || entry.URL == "" {
+		return ""
+	}
+	folder := appConfig.ScreenshotFolder
+	if !filepath.IsAbs(folder) {
+		folder = p.options.BaseFolder + folder
+	}
+	name := strings.Trim(strings.NewReplacer("://", "-", ":", "-", "/", "-").Replace(entry.URL), "-")
+	for _, extension := range screenshotExtensions {
+		screenshot := filepath.Join(folder, name+extension)
+		if _, err := os.Stat(screenshot); err == nil {
+			return screenshot
+		}
+	}
+	return ""
+}
+
+func getPerceptualHashFromFile(filename string) (uint64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, err
+	}
+	return getPerceptualHash(img), nil
+}
+
+/*
+Calculates the difference hash of an image. The image is scaled down to 9x8 gray values and every bit of the hash
+states if the brightness increases from one value to its right neighbour.
+*/
+func getPerceptualHash(img image.Image) uint64 {
+	bounds := img.Bounds()
+	var gray [8][9]float64
+	for y := 0; y < 8; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/8
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/8
+		for x := 0; x < 9; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/9
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/9
+			gray[y][x] = getAverageBrightness(img, x0, y0, x1, y1)
+		}
+	}
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if gray[y][x] < gray[y][x+1] {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return hash
+}
+
+func getAverageBrightness(img image.Image, x0 int, y0 int, x1 int, y1 int) float64 {
+	// Sample at most 16x16 pixels per cell, this is accurate enough for large screenshots
+	stepX := (x1-x0)/16 + 1
+	stepY := (y1-y0)/16 + 1
+	var sum float64
+	var count int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
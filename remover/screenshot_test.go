@@ -0,0 +1,66 @@
+package remover
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes a gray gradient, getting brighter from left to right or the other way round.
+func writeTestScreenshot(t *testing.T, filename string, increasing bool, noise uint8) {
+	img := image.NewGray(image.Rect(0, 0, 180, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 180; x++ {
+			value := uint8(x)
+			if !increasing {
+				value = uint8(179 - x)
+			}
+			img.SetGray(x, y, color.Gray{Y: value + uint8(y%2)*noise})
+		}
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeduplicateByScreenshot(t *testing.T) {
+	folder := t.TempDir()
+	writeTestScreenshot(t, filepath.Join(folder, "a.png"), true, 0)
+	writeTestScreenshot(t, filepath.Join(folder, "b.png"), true, 1)
+	writeTestScreenshot(t, filepath.Join(folder, "https-c.example.com-443.png"), false, 0)
+	appConfig.ScreenshotFolder = folder
+	defer func() { appConfig.ScreenshotFolder = "" }()
+
+	entries := []SimpleHTTPXEntry{
+		{Input: "a.example.com", URL: "https://a.example.com:443", ScreenshotPath: filepath.Join(folder, "a.png")},
+		{Input: "b.example.com", URL: "https://b.example.com:443", ScreenshotPath: filepath.Join(folder, "b.png")},
+		{Input: "c.example.com", URL: "https://c.example.com:443"},
+		{Input: "d.example.com", URL: "https://d.example.com:443"},
+	}
+	p := &Remover{options: &Options{Project: "example.com", ScreenshotDistance: 5}}
+	if screenshot := p.getScreenshotPath(entries[2]); screenshot != filepath.Join(folder, "https-c.example.com-443.png") {
+		t.Errorf("expected the screenshot to be found in the folder, got %q", screenshot)
+	}
+
+	remaining, duplicates := p.deduplicateByScreenshot(entries, make(map[string]SimpleHTTPXEntry))
+
+	if len(remaining) != 3 || containsEntryWithInput(remaining, "b.example.com") {
+		t.Errorf("expected b.example.com to be merged, got %+v", remaining)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected one visual cluster, got %+v", duplicates)
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.Hostname != "a.example.com" || len(duplicate.DuplicateHosts) != 1 || duplicate.KeyType != "screenshot" {
+			t.Errorf("unexpected visual cluster %+v", duplicate)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package remover
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"golang.org/x/net/html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Pages with fewer elements (e.g. plain error pages) are too generic for a structural comparison.
+const minSkeletonElements = 10
+
+/*
+Creates a fingerprint of the DOM structure of an HTML body. Text and attributes are ignored, only the tag path of
+every element (e.g. html/body/div/a) is used in the order of appearance. Returns an empty string if the body doesn't
+contain enough elements.
+*/
+func getSkeletonFingerprint(body string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	var stack []string
+	var paths []string
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+		name, _ := tokenizer.TagName()
+		tag := string(name)
+		switch tokenType {
+		case html.StartTagToken:
+			paths = append(paths, strings.Join(append(stack, tag), "/"))
+			if !isVoidElement(tag) {
+				stack = append(stack, tag)
+			}
+		case html.SelfClosingTagToken:
+			paths = append(paths, strings.Join(append(stack, tag), "/"))
+		case html.EndTagToken:
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+	if len(paths) < minSkeletonElements {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(strings.Join(paths, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+/*
+Clusters the entries which share the same DOM skeleton fingerprint. These are typically different tenants of the same
+CMS template, which differ in text but not in structure. They are labeled as template duplicates to distinguish them
+from exact duplicates.
+*/
+func (p *Remover) deduplicateBySkeleton(entries []SimpleHTTPXEntry, tlds map[string]SimpleHTTPXEntry) ([]SimpleHTTPXEntry, map[string]Duplicates) {
+	duplicates := make(map[string]Duplicates)
+	entriesPerSkeleton := make(map[string][]SimpleHTTPXEntry)
+	var remaining []SimpleHTTPXEntry
+	for _, entry := range entries {
+		if entry.Skeleton == "" {
+			remaining = append(remaining, entry)
+			continue
+		}
+		entriesPerSkeleton[entry.Skeleton] = append(entriesPerSkeleton[entry.Skeleton], entry)
+	}
+	// Sorted, so that the same representatives and members are used in every run
+	skeletons := make([]string, 0, len(entriesPerSkeleton))
+	for skeleton := range entriesPerSkeleton {
+		skeletons = append(skeletons, skeleton)
+	}
+	sort.Strings(skeletons)
+	for _, skeleton := range skeletons {
+		skeletonEntries := entriesPerSkeleton[skeleton]
+		sort.Slice(skeletonEntries, func(i, j int) bool {
+			return skeletonEntries[i].Input < skeletonEntries[j].Input
+		})
+		if len(skeletonEntries) == 1 {
+			remaining = append(remaining, skeletonEntries[0])
+			continue
+		}
+		representative := getBestDuplicateMatch(skeletonEntries, p.options.Project, tlds)
+		if representative.Input == "" {
+			representative = skeletonEntries[0]
+		}
+		remaining = append(remaining, representative)
+		duplicate := getDuplicate(representative)
+		duplicate.Reason = "template duplicate"
+		duplicate.KeyType = "skeleton"
+		duplicate.Key = skeleton
+		duplicate.Evidence = "same DOM skeleton " + skeleton
+		for _, entry := range skeletonEntries {
+			if entry.Input != representative.Input {
+				duplicate.DuplicateHosts = AppendIfMissing(duplicate.DuplicateHosts, entry.Input)
+			}
+		}
+		duplicates["skeleton-"+skeleton] = duplicate
+	}
+	return remaining, duplicates
+}
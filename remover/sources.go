@@ -0,0 +1,92 @@
+package remover
+
+import (
+	"github.com/antchfx/jsonquery"
+	"sort"
+	"strings"
+)
+
+var (
+	// Enumeration sources per hostname, from the subfinder and amass outputs
+	hostSources = make(map[string][]string)
+	// If set, hostnames confirmed by more sources are preferred as best match
+	preferConfirmedHosts bool
+)
+
+type HostSources struct {
+	Host    string
+	Sources []string
+}
+
+/*
+Reads the sources per hostname from a subfinder (-oJ) or amass (-json) output. Subfinder provides the hostname as host
+and either a single source or a list of sources, amass provides the hostname as name and a list of sources.
+*/
+func GetSourcesFromEnumeration(filename string) map[string][]string {
+	sourcesPerHost := make(map[string][]string)
+	entries, err := jsonquery.QueryAll(GetDocumentFromFiles([]string{filename}), "/*")
+	if err != nil {
+		log.Errorf("Querying JSON error   #%v ", err)
+	}
+	for _, node := range entries {
+		entryValues, ok := node.Value().(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, ok := entryValues["host"].(string)
+		if !ok {
+			host, _ = entryValues["name"].(string)
+		}
+		if host == "" {
+			continue
+		}
+		host = strings.ToLower(host)
+		var sources []string
+		sources = append(sources, getStringsFromValue(entryValues["source"])...)
+		sources = append(sources, getStringsFromValue(entryValues["sources"])...)
+		for _, source := range sources {
+			sourcesPerHost[host] = AppendIfMissing(sourcesPerHost[host], strings.ToLower(source))
+		}
+	}
+	return sourcesPerHost
+}
+
+// Loads the sources from all configured enumeration outputs which exist.
+func (p *Remover) loadHostSources() {
+	for _, sourcesFile := range []string{appConfig.SubfinderFile, appConfig.AmassFile} {
+		if sourcesFile == "" {
+			continue
+		}
+		sourcesFile = p.options.BaseFolder + "recon/" + sourcesFile
+		if !CheckIfFileExists(sourcesFile, false) {
+			continue
+		}
+		log.Infof("Using enumeration sources input %s", sourcesFile)
+		for host, sources := range GetSourcesFromEnumeration(sourcesFile) {
+			hostSources[host] = AppendSliceIfMissing(hostSources[host], sources)
+		}
+	}
+	for host := range hostSources {
+		sort.Strings(hostSources[host])
+	}
+}
+
+// Returns the enumeration sources of the hostname (port is ignored).
+func getSourcesForHost(input string) []string {
+	host, _ := getHostAndPort(input)
+	return hostSources[strings.ToLower(host)]
+}
+
+// Returns the sources of the representative and all duplicate hosts.
+func getSourcesForDuplicate(duplicate Duplicates) map[string][]string {
+	sources := make(map[string][]string)
+	for _, host := range append([]string{duplicate.Hostname}, duplicate.DuplicateHosts...) {
+		if hostSources := getSourcesForHost(host); len(hostSources) > 0 {
+			sources[host] = hostSources
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
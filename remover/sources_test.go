@@ -0,0 +1,48 @@
+package remover
+
+import (
+	"reflect"
+	"testing"
+)
+
+const subfinderOutput = `{"host":"www.example.com","input":"example.com","source":"crtsh"}
+{"host":"A.example.com","input":"example.com","sources":["crtsh","Anubis"]}
+`
+
+const amassOutput = `{"name":"a.example.com","domain":"example.com","sources":["DNS","crtsh"]}
+{"name":"b.example.com","domain":"example.com","sources":["DNS"]}
+`
+
+func TestGetSourcesFromEnumeration(t *testing.T) {
+	subfinderSources := GetSourcesFromEnumeration(writeTestFile(t, "subfinder.json", subfinderOutput))
+	expected := map[string][]string{"www.example.com": {"crtsh"}, "a.example.com": {"crtsh", "anubis"}}
+	if !reflect.DeepEqual(subfinderSources, expected) {
+		t.Errorf("expected %v, got %v", expected, subfinderSources)
+	}
+	amassSources := GetSourcesFromEnumeration(writeTestFile(t, "amass.json", amassOutput))
+	expected = map[string][]string{"a.example.com": {"dns", "crtsh"}, "b.example.com": {"dns"}}
+	if !reflect.DeepEqual(amassSources, expected) {
+		t.Errorf("expected %v, got %v", expected, amassSources)
+	}
+}
+
+func TestConfirmedHostIsPreferredAsBestMatch(t *testing.T) {
+	hostSources = map[string][]string{"b.example.com": {"crtsh", "dns"}, "a.example.com": {"dns"}}
+	defer func() {
+		hostSources = make(map[string][]string)
+		preferConfirmedHosts = false
+	}()
+	entries := []SimpleHTTPXEntry{{Input: "a.example.com:443"}, {Input: "b.example.com:443"}}
+
+	if match := getBestDuplicateMatch(entries, "example.com", make(map[string]SimpleHTTPXEntry)); match.Input != "a.example.com:443" {
+		t.Errorf("expected the first host without preferring confirmed hosts, got %s", match.Input)
+	}
+	preferConfirmedHosts = true
+	if match := getBestDuplicateMatch(entries, "example.com", make(map[string]SimpleHTTPXEntry)); match.Input != "b.example.com:443" {
+		t.Errorf("expected the host confirmed by more sources, got %s", match.Input)
+	}
+	duplicate := Duplicates{Hostname: "b.example.com:443", DuplicateHosts: []string{"a.example.com:443", "c.example.com"}}
+	if sources := getSourcesForDuplicate(duplicate); len(sources) != 2 || len(sources["b.example.com:443"]) != 2 {
+		t.Errorf("expected the sources of the representative and a.example.com, got %v", sources)
+	}
+}
@@ -0,0 +1,125 @@
+package remover
+
+import (
+	"encoding/json"
+	"github.com/antchfx/jsonquery"
+	"sort"
+	"strings"
+)
+
+// Fingerprint of a service as used by can-i-take-over-xyz (fingerprints.json)
+type TakeoverFingerprint struct {
+	Service     string   `json:"service"`
+	CNAMEs      []string `json:"cname"`
+	Fingerprint string   `json:"fingerprint"`
+	NXDomain    bool     `json:"nxdomain"`
+	Status      string   `json:"status"`
+}
+
+type TakeoverCandidate struct {
+	Host        string
+	CNAMEs      []string
+	Service     string
+	Reason      string
+	Fingerprint string
+}
+
+func loadTakeoverFingerprints(filename string) []TakeoverFingerprint {
+	var fingerprints []TakeoverFingerprint
+	data, err := ReadFile(filename)
+	if err != nil {
+		log.Errorf("Reading takeover fingerprints failed: %s", err)
+		return fingerprints
+	}
+	if err := json.Unmarshal(data, &fingerprints); err != nil {
+		log.Errorf("Parsing takeover fingerprints failed: %s", err)
+	}
+	return fingerprints
+}
+
+/*
+Returns the hosts whose CNAME chain points to a service from the fingerprints which either doesn't resolve (only for
+services which are vulnerable on NXDOMAIN), has no HTTP response or responds with the "not found" page (body signature)
+of the service. All indicate a dangling CNAME which could be taken over. Services which are marked as not vulnerable
+are ignored.
+*/
+func GetTakeoverCandidates(records []DNSRecord, httpxInput *jsonquery.Node, fingerprints []TakeoverFingerprint) []TakeoverCandidate {
+	bodies := getResponseBodiesPerHost(httpxInput)
+	var candidates []TakeoverCandidate
+	for _, record := range records {
+		if len(record.CNAMEs) == 0 {
+			continue
+		}
+		fingerprint, found := getTakeoverFingerprintForCNAMEs(record.CNAMEs, fingerprints)
+		if !found {
+			continue
+		}
+		candidate := TakeoverCandidate{
+			Host:    record.Host,
+			CNAMEs:  record.CNAMEs,
+			Service: fingerprint.Service,
+		}
+		body, hasResponse := bodies[record.Host]
+		if len(record.IPv4Addresses) == 0 && len(record.IPv6Addresses) == 0 {
+			// A CNAME target which doesn't resolve can only be claimed if the service is vulnerable on NXDOMAIN
+			if !fingerprint.NXDomain {
+				continue
+			}
+			candidate.Reason = "CNAME target doesn't resolve"
+		} else if !hasResponse {
+			candidate.Reason = "no HTTP response"
+		} else if fingerprint.Fingerprint != "" && strings.Contains(body, fingerprint.Fingerprint) {
+			candidate.Reason = "service not found page"
+			candidate.Fingerprint = fingerprint.Fingerprint
+		} else {
+			continue
+		}
+		log.Infof("Found takeover candidate %s (%s, %s)", record.Host, fingerprint.Service, candidate.Reason)
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Host < candidates[j].Host
+	})
+	return candidates
+}
+
+func getTakeoverFingerprintForCNAMEs(cnames []string, fingerprints []TakeoverFingerprint) (TakeoverFingerprint, bool) {
+	for _, fingerprint := range fingerprints {
+		if strings.EqualFold(fingerprint.Status, "not vulnerable") {
+			continue
+		}
+		for _, cname := range cnames {
+			cname = strings.TrimSuffix(strings.ToLower(cname), ".")
+			for _, suffix := range fingerprint.CNAMEs {
+				suffix = strings.TrimPrefix(strings.ToLower(suffix), ".")
+				if suffix != "" && (cname == suffix || strings.HasSuffix(cname, "."+suffix)) {
+					return fingerprint, true
+				}
+			}
+		}
+	}
+	return TakeoverFingerprint{}, false
+}
+
+// Returns the raw body (or title if no body is included) of every hostname with an HTTP response.
+func getResponseBodiesPerHost(document *jsonquery.Node) map[string]string {
+	bodies := make(map[string]string)
+	entries, err := jsonquery.QueryAll(document, "/*")
+	if err != nil {
+		log.Errorf("Querying JSON error   #%v ", err)
+	}
+	for _, node := range entries {
+		entryValues, ok := node.Value().(map[string]interface{})
+		if !ok {
+			continue
+		}
+		input, _ := entryValues["input"].(string)
+		host, _ := getHostAndPort(input)
+		body, _ := entryValues["body"].(string)
+		if body == "" {
+			body, _ = entryValues["title"].(string)
+		}
+		bodies[host] = bodies[host] + body
+	}
+	return bodies
+}
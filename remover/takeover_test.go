@@ -0,0 +1,42 @@
+package remover
+
+import (
+	"testing"
+)
+
+func TestGetTakeoverCandidates(t *testing.T) {
+	fingerprints := []TakeoverFingerprint{
+		{Service: "Nxdomain Service", CNAMEs: []string{"nx.net"}, Fingerprint: "NXDOMAIN", NXDomain: true, Status: "Vulnerable"},
+		{Service: "Bucket Service", CNAMEs: []string{"bucket.net"}, Fingerprint: "NoSuchBucket", Status: "Vulnerable"},
+		{Service: "Safe Service", CNAMEs: []string{"safe.net"}, Fingerprint: "Not found", Status: "Not vulnerable"},
+	}
+	records := []DNSRecord{
+		{Host: "a.example.com", CNAMEs: []string{"a.nx.net."}},
+		{Host: "b.example.com", CNAMEs: []string{"b.bucket.net"}},
+		{Host: "c.example.com", CNAMEs: []string{"c.bucket.net"}, IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "d.example.com", CNAMEs: []string{"d.bucket.net"}, IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "e.example.com", CNAMEs: []string{"e.bucket.net"}, IPv4Addresses: []string{"1.2.3.4"}},
+		{Host: "f.example.com", CNAMEs: []string{"f.safe.net"}},
+		{Host: "g.example.com", IPv4Addresses: []string{"1.2.3.4"}},
+	}
+	httpxInput := GetDocumentFromHTTPXRecords([]HTTPXRecord{
+		newHTTPXRecord("d.example.com", "1.2.3.4", "<Error><Code>NoSuchBucket</Code></Error>"),
+		newHTTPXRecord("e.example.com", "1.2.3.4", "<html><body>Hello</body></html>"),
+	})
+
+	candidates := GetTakeoverCandidates(records, httpxInput, fingerprints)
+
+	expected := map[string]string{
+		"a.example.com": "CNAME target doesn't resolve",
+		"c.example.com": "no HTTP response",
+		"d.example.com": "service not found page",
+	}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates, got %+v", len(expected), candidates)
+	}
+	for _, candidate := range candidates {
+		if reason, ok := expected[candidate.Host]; !ok || candidate.Reason != reason {
+			t.Errorf("expected %s with reason %q, got %+v", candidate.Host, reason, candidate)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package remover
+
+import (
+	"sort"
+	"strings"
+)
+
+type TLSCluster struct {
+	Fingerprint string
+	JARM        string
+	TLSVersion  string
+	TLSCipher   string
+	IPs         []string
+	Hosts       []string
+}
+
+/*
+Reads the JARM hash and the TLS data from the HTTPX entry. Depending on the HTTPX version the JARM hash is provided
+as jarm or jarm_hash and the TLS data as tls or tls-grab.
+*/
+func setTLSDataFromHTTPX(entry *SimpleHTTPXEntry, entryValues map[string]interface{}) {
+	if jarm, ok := entryValues["jarm"].(string); ok {
+		entry.JARM = jarm
+	} else if jarm, ok := entryValues["jarm_hash"].(string); ok {
+		entry.JARM = jarm
+	}
+	tlsValues, ok := entryValues["tls"].(map[string]interface{})
+	if !ok {
+		tlsValues, ok = entryValues["tls-grab"].(map[string]interface{})
+	}
+	if !ok {
+		return
+	}
+	if version, ok := tlsValues["tls_version"].(string); ok {
+		entry.TLSVersion = version
+	}
+	if cipher, ok := tlsValues["cipher"].(string); ok {
+		entry.TLSCipher = cipher
+	}
+	if fingerprints, ok := tlsValues["fingerprint_hash"].(map[string]interface{}); ok {
+		if sha256, ok := fingerprints["sha256"].(string); ok {
+			entry.CertificateHash = sha256
+		}
+	}
+}
+
+// Returns the combined TLS fingerprint of the entry or an empty string if no TLS data is available.
+func (entry SimpleHTTPXEntry) tlsFingerprint() string {
+	if entry.JARM == "" && entry.TLSVersion == "" && entry.TLSCipher == "" && entry.CertificateHash == "" {
+		return ""
+	}
+	return strings.Join([]string{entry.JARM, entry.TLSVersion, entry.TLSCipher, entry.CertificateHash}, "/")
+}
+
+/*
+Clusters all HTTPX entries by their TLS fingerprint across IP addresses. Only clusters spanning more than one IP are
+returned, since these are likely the same TLS endpoint (e.g. load balancer or CDN) reachable via different IPs.
+*/
+func GetTLSClusters(entries []SimpleHTTPXEntry) []TLSCluster {
+	clusters := make(map[string]TLSCluster)
+	for _, entry := range entries {
+		fingerprint := entry.tlsFingerprint()
+		if fingerprint == "" || entry.JARM == "" {
+			continue
+		}
+		cluster, ok := clusters[fingerprint]
+		if !ok {
+			cluster = TLSCluster{
+				Fingerprint: fingerprint,
+				JARM:        entry.JARM,
+				TLSVersion:  entry.TLSVersion,
+				TLSCipher:   entry.TLSCipher,
+			}
+		}
+		cluster.IPs = AppendIfMissing(cluster.IPs, entry.Host)
+		cluster.Hosts = AppendIfMissing(cluster.Hosts, entry.Input)
+		clusters[fingerprint] = cluster
+	}
+
+	var result []TLSCluster
+	for _, cluster := range clusters {
+		if len(cluster.IPs) > 1 {
+			sort.Strings(cluster.IPs)
+			sort.Strings(cluster.Hosts)
+			result = append(result, cluster)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
@@ -1,38 +1,105 @@
 package remover
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
 const VERSION = "0.2.3"
 
 type Config struct {
-	S2SPath          string `yaml:"s2s_path,omitempty"`
-	HttpxDomainsFile string `yaml:"httpx_domains,omitempty"`
-	DpuxFile         string `yaml:"dpux,omitempty"`
-	DpuxIPFile       string `yaml:"dpux_ip,omitempty"`
+	S2SPath             string              `yaml:"s2s_path,omitempty"`
+	HttpxDomainsFile    string              `yaml:"httpx_domains,omitempty"`
+	HttpxIPsFile        string              `yaml:"httpx_ips,omitempty"`
+	HttpxInputs         []string            `yaml:"httpx_inputs,omitempty"`
+	HttpxCleanFile      string              `yaml:"httpx_clean,omitempty"`
+	HttpxDuplicatesFile string              `yaml:"httpx_duplicates,omitempty"`
+	DpuxFile            string              `yaml:"dpux,omitempty"`
+	DnsmxFile           string              `yaml:"dnsmx,omitempty"`
+	DpuxIPFile          string              `yaml:"dpux_ip,omitempty"`
+	NeverMergeStatus    []int               `yaml:"never_merge_status,omitempty"`
+	Normalization       NormalizationConfig `yaml:"normalization,omitempty"`
+	ScreenshotFolder    string              `yaml:"screenshots,omitempty"`
+	TakeoverFile        string              `yaml:"takeover_fingerprints,omitempty"`
+	ASNDatabaseFile     string              `yaml:"asn_database,omitempty"`
+	OwnedOrganizations  []string            `yaml:"owned_organizations,omitempty"`
+	OwnedASNs           []string            `yaml:"owned_asns,omitempty"`
+	PortsXMLFile        string              `yaml:"ports_xml,omitempty"`
+	PortsSimpleFile     string              `yaml:"ports_simple,omitempty"`
+	NaabuFile           string              `yaml:"naabu,omitempty"`
+	MasscanFile         string              `yaml:"masscan,omitempty"`
+	SubfinderFile       string              `yaml:"subfinder,omitempty"`
+	AmassFile           string              `yaml:"amass,omitempty"`
+}
+
+type NormalizationConfig struct {
+	MaskHostname       bool          `yaml:"mask_hostname"`
+	CollapseWhitespace bool          `yaml:"collapse_whitespace"`
+	StripScripts       bool          `yaml:"strip_scripts"`
+	StripStyles        bool          `yaml:"strip_styles"`
+	Replacements       []Replacement `yaml:"replacements,omitempty"`
+}
+
+type Replacement struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
 }
 
 type Remover struct {
-	options *Options
+	options     *Options
+	rateLimiter *time.Ticker
 }
 
 type SimpleHTTPXEntry struct {
-	Host          string
-	BodyHash      string
-	Status        int
-	ContentLength int
-	Lines         int
-	Words         int
-	Input         string
-	URL           string
-	Title         string
+	Host            string
+	BodyHash        string
+	Status          int
+	ContentLength   int
+	Lines           int
+	Words           int
+	Input           string
+	URL             string
+	Title           string
+	Skeleton        string
+	Headers         map[string][]string
+	HeaderHash      string
+	Technologies    []string
+	JARM            string
+	TLSVersion      string
+	TLSCipher       string
+	CertificateHash string
+	ScreenshotPath  string
+	InputFile       string
+}
+
+func (entry SimpleHTTPXEntry) isEmpty() bool {
+	return entry.Input == "" && entry.Host == "" && entry.URL == ""
 }
 
 type DNSRecord struct {
-	Host          string   `yaml:"host"`
-	IPv4Addresses []string `yaml:"ipv4"`
-	IPv6Addresses []string `yaml:"ipv6,omitempty"`
-	WhoisInfo     string   `yaml:"whois,omitempty"`
+	Host          string    `yaml:"host"`
+	IPv4Addresses []string  `yaml:"ipv4"`
+	IPv6Addresses []string  `yaml:"ipv6,omitempty"`
+	CNAMEs        []string  `yaml:"cname,omitempty"`
+	MXRecords     []string  `yaml:"mx,omitempty"`
+	NSRecords     []string  `yaml:"ns,omitempty"`
+	TXTRecords    []string  `yaml:"txt,omitempty"`
+	SOARecords    []string  `yaml:"soa,omitempty"`
+	TTL           int       `yaml:"ttl,omitempty"`
+	WhoisInfo     string    `yaml:"whois,omitempty"`
+	ASNs          []ASNInfo `yaml:"asn,omitempty"`
+}
+
+type CNAMECluster struct {
+	Target string
+	Hosts  []string
 }
 
 type Duplicates struct {
+	ClusterID      string
 	Hostname       string
 	IP             string
 	URL            string
@@ -41,6 +108,16 @@ type Duplicates struct {
 	Lines          int
 	Words          int
 	Status         int
+	HeaderHash     string
+	Technologies   []string
+	JARM           string
+	KeyType        string
+	Key            string
+	Reason         string
+	Evidence       string
+	InputFiles     map[string]string
+	Ports          []OpenPort
+	Sources        map[string][]string
 	DuplicateHosts []string
 }
 
@@ -54,7 +131,35 @@ func getDuplicate(entry SimpleHTTPXEntry) Duplicates {
 		Words:          entry.Words,
 		URL:            entry.URL,
 		Status:         entry.Status,
+		HeaderHash:     entry.HeaderHash,
+		Technologies:   entry.Technologies,
+		JARM:           entry.JARM,
 		DuplicateHosts: []string{},
 	}
 	return duplicate
 }
+
+/*
+Returns the ID of the cluster, derived from the key and the sorted members. Therefore, the same cluster gets the same ID
+in every run, independent of the order in which the hosts have been processed.
+*/
+func getClusterID(duplicate Duplicates) string {
+	members := append([]string{duplicate.Hostname}, duplicate.DuplicateHosts...)
+	sort.Strings(members)
+	hash := sha256.Sum256([]byte(duplicate.KeyType + "|" + duplicate.Key + "|" + duplicate.IP + "|" + strings.Join(members, ",")))
+	return duplicate.KeyType + "-" + hex.EncodeToString(hash[:])[:12]
+}
+
+// Sorts the duplicate hosts of every cluster and the clusters by representative and ID, after assigning their IDs.
+func sortDuplicates(duplicates []Duplicates) {
+	for index := range duplicates {
+		sort.Strings(duplicates[index].DuplicateHosts)
+		duplicates[index].ClusterID = getClusterID(duplicates[index])
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Hostname != duplicates[j].Hostname {
+			return duplicates[i].Hostname < duplicates[j].Hostname
+		}
+		return duplicates[i].ClusterID < duplicates[j].ClusterID
+	})
+}
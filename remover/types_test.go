@@ -0,0 +1,79 @@
+package remover
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClusterIDIsIndependentOfMemberOrder(t *testing.T) {
+	first := Duplicates{Hostname: "www.example.com", IP: "10.0.0.1", KeyType: "hash", Key: "111",
+		DuplicateHosts: []string{"a.example.com", "b.example.com"}}
+	second := first
+	second.DuplicateHosts = []string{"b.example.com", "a.example.com"}
+	if getClusterID(first) != getClusterID(second) {
+		t.Errorf("expected the same cluster ID, got %s and %s", getClusterID(first), getClusterID(second))
+	}
+	third := first
+	third.KeyType = "words-lines"
+	if getClusterID(first) == getClusterID(third) {
+		t.Errorf("expected different cluster IDs for different key types")
+	}
+}
+
+func TestSortDuplicates(t *testing.T) {
+	duplicates := []Duplicates{
+		{Hostname: "www.example.com", KeyType: "hash", Key: "111", DuplicateHosts: []string{"b.example.com", "a.example.com"}},
+		{Hostname: "mail.example.com", KeyType: "dns", Key: "10.0.0.2", DuplicateHosts: []string{"vpn.example.com"}},
+	}
+	sortDuplicates(duplicates)
+	if duplicates[0].Hostname != "mail.example.com" || duplicates[1].Hostname != "www.example.com" {
+		t.Errorf("expected the clusters to be sorted by representative, got %+v", duplicates)
+	}
+	if !reflect.DeepEqual(duplicates[1].DuplicateHosts, []string{"a.example.com", "b.example.com"}) {
+		t.Errorf("expected sorted duplicate hosts, got %v", duplicates[1].DuplicateHosts)
+	}
+	if duplicates[0].ClusterID == "" || duplicates[0].ClusterID == duplicates[1].ClusterID {
+		t.Errorf("expected distinct cluster IDs, got %s and %s", duplicates[0].ClusterID, duplicates[1].ClusterID)
+	}
+}
+
+func TestDeduplicationIsDeterministic(t *testing.T) {
+	template := "<html><head><title>%s</title></head><body><div><h1>%s</h1><p>%s</p><ul><li>a</li><li>b</li></ul></div></body></html>"
+	other := "<html><head><title>%s</title></head><body><main><h2>%s</h2><section>%s</section><ol><li>a</li><li>b</li></ol></main></body></html>"
+	var records []HTTPXRecord
+	for index, host := range []string{"d", "b", "f", "a", "e", "c", "h", "g"} {
+		body := template
+		if index%2 == 0 {
+			body = other
+		}
+		records = append(records, newHTTPXRecord(host+".example.com", "1.2.3.4",
+			fmt.Sprintf(body, host, strings.Repeat("word ", index+1), host)))
+	}
+	// Different bodies with the same words and lines
+	for _, host := range []string{"wl-c", "wl-a", "wl-d", "wl-b"} {
+		records = append(records, newHTTPXRecord(host+".example.com", "1.2.3.4", "<"+host+">"+strings.Repeat(host+" ", 30)+"</"+host+">"))
+	}
+	initializeHashScheme(records)
+	p := &Remover{options: &Options{Project: "example.com", Scope: "port", TemplateCheck: true}}
+	document := GetDocumentFromHTTPXRecords(records)
+
+	var expected []Duplicates
+	for run := 0; run < 10; run++ {
+		_, duplicates := p.deduplicateByContent(document, "1.2.3.4")
+		var result []Duplicates
+		for _, duplicate := range duplicates {
+			result = append(result, duplicate)
+		}
+		sortDuplicates(result)
+		if run == 0 {
+			expected = result
+			if len(expected) != 3 {
+				t.Fatalf("expected two template clusters and one words and lines cluster, got %+v", expected)
+			}
+		} else if !reflect.DeepEqual(expected, result) {
+			t.Fatalf("expected the same clusters in every run, got %+v and %+v", expected, result)
+		}
+	}
+}
@@ -2,17 +2,25 @@ package remover
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/tls"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"sort"
 	"strings"
+	"time"
 )
 
 var (
 	client = http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}}
@@ -29,6 +37,11 @@ func RandStringRunes(n int) string {
 }
 
 func WriteToTextFileInProject(filename string, data string) {
+	compressed, err := compressForFile(filename, []byte(data))
+	if err != nil {
+		log.Fatalf("failed compressing file: %s", err)
+	}
+
 	writeFile, err := os.Create(filename)
 	if err != nil {
 		log.Fatalf("failed creating file: %s", err)
@@ -39,7 +52,7 @@ func WriteToTextFileInProject(filename string, data string) {
 	if err != nil {
 		log.Error(err)
 	}
-	dataWriter.WriteString(data)
+	dataWriter.Write(compressed)
 	dataWriter.Flush()
 	writeFile.Close()
 }
@@ -70,6 +83,10 @@ func ExtractDomainAndTldFromString(str string) string {
 
 }
 
+func isIPAddress(host string) bool {
+	return net.ParseIP(host) != nil
+}
+
 func subDomainCount(host string) int {
 	parts := strings.Split(host, ".")
 	return len(parts)
@@ -90,7 +107,7 @@ func getHostAndPort(input string) (string, string) {
 
 func AppendDuplicatesIfMissing(slice []Duplicates, key Duplicates) []Duplicates {
 	for _, element := range slice {
-		if element.Hostname == key.Hostname {
+		if element.Hostname == key.Hostname && element.IP == key.IP && element.KeyType == key.KeyType && element.Key == key.Key {
 			log.Debugf("%s already exists in the slice.", key.Hostname)
 			return slice
 		}
@@ -227,14 +244,13 @@ func CheckIfFileExists(path string, stopRunning bool) bool {
 
 func ReadTxtFileLines(path string) []string {
 	var lines []string
-	f, err := os.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	data, err := ReadFile(path)
 	if err != nil {
 		log.Fatalf("open file error: %v", err)
 		return []string{}
 	}
-	defer f.Close()
 
-	rd := bufio.NewReader(f)
+	rd := bufio.NewReader(bytes.NewReader(data))
 	for {
 		line, err := rd.ReadString('\n')
 		line = strings.TrimSuffix(line, "\n")
@@ -0,0 +1,186 @@
+package remover
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+type verificationResponse struct {
+	Status int
+	Body   string
+}
+
+/*
+Re-fetches the representative and a sample of the duplicate hosts of every duplicates entry and compares the responses
+using the signal the duplicates entry was built from (see responsesMatch). All requests are sent to the IP address of the duplicates, using the hostname for the Host header and SNI.
+Duplicate hosts whose response doesn't match the representative anymore are removed from the duplicates entry and
+returned with the data of the fetched response, since the passive HTTPX data was stale or flaky for them. Hosts which
+can't be fetched and visual duplicates, which can't be compared without a screenshot, are kept as they are.
+*/
+func (p *Remover) verifyDuplicates(ipaddress string, entries []SimpleHTTPXEntry, duplicates map[string]Duplicates) []SimpleHTTPXEntry {
+	verificationClient := getClientForIPAddress(ipaddress)
+	entriesByInput := make(map[string]SimpleHTTPXEntry)
+	for _, entry := range entries {
+		entriesByInput[entry.Input] = entry
+	}
+
+	var split []SimpleHTTPXEntry
+	keys := make([]string, 0, len(duplicates))
+	for key := range duplicates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		duplicate := duplicates[key]
+		representative, ok := entriesByInput[duplicate.Hostname]
+		if !ok || duplicate.KeyType == "screenshot" {
+			continue
+		}
+		expected, err := p.fetchForVerification(verificationClient, representative.URL)
+		if err != nil {
+			log.Debugf("Could not verify duplicates of %s: %s", representative.Input, err)
+			continue
+		}
+
+		var sample []SimpleHTTPXEntry
+		for _, index := range rand.Perm(len(duplicate.DuplicateHosts)) {
+			if len(sample) >= p.options.VerifySample {
+				break
+			}
+			if entry, ok := entriesByInput[duplicate.DuplicateHosts[index]]; ok {
+				sample = append(sample, entry)
+			}
+		}
+
+		var mismatched []SimpleHTTPXEntry
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+		// Limits the concurrent requests to the same host (all entries are on the same IP)
+		slots := make(chan struct{}, p.options.Concurrency)
+		for _, entry := range sample {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(entry SimpleHTTPXEntry) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				actual, err := p.fetchForVerification(verificationClient, entry.URL)
+				if err != nil {
+					log.Debugf("Could not verify duplicate %s: %s", entry.Input, err)
+					return
+				}
+				if !responsesMatch(duplicate.KeyType, expected, getHostname(representative.URL), actual, getHostname(entry.URL)) {
+					entry.Status = actual.Status
+					entry.ContentLength = len(actual.Body)
+					entry.Words, entry.Lines = countWordsAndLines(actual.Body)
+					entry.BodyHash = getNormalizedBodyHash(actual.Body, getHostname(entry.URL))
+					mutex.Lock()
+					mismatched = append(mismatched, entry)
+					mutex.Unlock()
+				}
+			}(entry)
+		}
+		wg.Wait()
+
+		if len(mismatched) == 0 {
+			continue
+		}
+		var remainingHosts []string
+		for _, host := range duplicate.DuplicateHosts {
+			if !containsEntryWithInput(mismatched, host) {
+				remainingHosts = append(remainingHosts, host)
+			}
+		}
+		for _, entry := range mismatched {
+			log.Infof("Host %s doesn't match %s anymore, removing it from duplicates", entry.Input, duplicate.Hostname)
+		}
+		split = append(split, mismatched...)
+		if len(remainingHosts) == 0 {
+			delete(duplicates, key)
+		} else {
+			duplicate.DuplicateHosts = remainingHosts
+			duplicates[key] = duplicate
+		}
+	}
+	return split
+}
+
+/*
+Checks if the response of a duplicate host still matches the response of the representative. The status must always be
+the same. Hash duplicates must have the same normalized body, template duplicates the same DOM skeleton and all other
+duplicates (words and lines, catch-all) the same number of words and lines.
+*/
+func responsesMatch(keyType string, expected verificationResponse, expectedHost string, actual verificationResponse, actualHost string) bool {
+	if actual.Status != expected.Status {
+		return false
+	}
+	switch keyType {
+	case "hash":
+		return normalizeBody(actual.Body, actualHost) == normalizeBody(expected.Body, expectedHost)
+	case "skeleton":
+		return getSkeletonFingerprint(actual.Body) == getSkeletonFingerprint(expected.Body)
+	default:
+		actualWords, actualLines := countWordsAndLines(actual.Body)
+		expectedWords, expectedLines := countWordsAndLines(expected.Body)
+		return actualWords == expectedWords && actualLines == expectedLines
+	}
+}
+
+/*
+Returns a client which connects to the IP address for every request, independent of what the hostname currently
+resolves to. Since the URL keeps the hostname, it is used for the Host header and SNI.
+*/
+func getClientForIPAddress(ipaddress string) *http.Client {
+	dialer := &net.Dialer{Timeout: client.Timeout}
+	ipClient := client
+	ipClient.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ipaddress, port))
+		},
+	}
+	return &ipClient
+}
+
+func (p *Remover) fetchForVerification(verificationClient *http.Client, target string) (verificationResponse, error) {
+	if p.rateLimiter != nil {
+		<-p.rateLimiter.C
+	}
+	response, err := verificationClient.Get(target)
+	if err != nil {
+		return verificationResponse{}, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return verificationResponse{}, err
+	}
+	return verificationResponse{Status: response.StatusCode, Body: string(body)}, nil
+}
+
+func containsEntryWithInput(entries []SimpleHTTPXEntry, input string) bool {
+	for _, entry := range entries {
+		if entry.Input == input {
+			return true
+		}
+	}
+	return false
+}
+
+func getHostname(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,73 @@
+package remover
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestVerifyDuplicatesUsesIPAndReclustersSplitHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if host == "www.example.com" {
+			fmt.Fprint(w, "original content")
+			return
+		}
+		fmt.Fprint(w, "changed content")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	ipaddress, port, _ := net.SplitHostPort(serverURL.Host)
+
+	var entries []SimpleHTTPXEntry
+	for _, host := range []string{"www.example.com", "a.example.com", "b.example.com"} {
+		// The hostnames don't resolve, the requests must be sent to the IP address of the entries
+		entries = append(entries, SimpleHTTPXEntry{Input: host + ":" + port, Host: ipaddress, URL: "http://" + host + ":" + port,
+			Status: 200, BodyHash: "111", Words: 2, Lines: 1})
+	}
+	duplicate := getDuplicate(entries[0])
+	duplicate.KeyType = "hash"
+	duplicate.DuplicateHosts = []string{entries[1].Input, entries[2].Input}
+	duplicates := map[string]Duplicates{"111": duplicate}
+
+	p := &Remover{options: &Options{Project: "example.com", VerifySample: 3, Concurrency: 2}}
+	split := p.verifyDuplicates(ipaddress, entries, duplicates)
+	if len(split) != 2 {
+		t.Fatalf("expected two hosts to be split, got %+v", split)
+	}
+	if _, ok := duplicates["111"]; ok {
+		t.Errorf("expected the duplicates without remaining hosts to be removed")
+	}
+
+	cleaned, splitDuplicates := p.deduplicateGroup(split, make(map[string]SimpleHTTPXEntry))
+	if len(cleaned) != 1 || len(splitDuplicates) != 1 {
+		t.Errorf("expected the split hosts to be clustered with each other, got %+v and %+v", cleaned, splitDuplicates)
+	}
+}
+
+func TestResponsesMatchUsesTheClusterSignal(t *testing.T) {
+	page := "<html><head><title>Shop</title></head><body><div><h1>%s</h1><p>%s</p><ul><li>a</li><li>b</li></ul></div></body></html>"
+	tests := []struct {
+		keyType string
+		actual  verificationResponse
+		match   bool
+	}{
+		{"hash", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Welcome", "text")}, true},
+		{"hash", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Goodbye", "text")}, false},
+		{"hash", verificationResponse{Status: 404, Body: fmt.Sprintf(page, "Welcome", "text")}, false},
+		{"words-lines", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Goodbye", "text")}, true},
+		{"words-lines", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Goodbye", "more text")}, false},
+		{"catch-all", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Goodbye", "text")}, true},
+		{"skeleton", verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Another title", "a lot more text")}, true},
+		{"skeleton", verificationResponse{Status: 200, Body: "<html><head><title>Shop</title></head><body><main><h2>Welcome</h2><section>text</section><ol><li>a</li><li>b</li></ol></main></body></html>"}, false},
+	}
+	expected := verificationResponse{Status: 200, Body: fmt.Sprintf(page, "Welcome", "text")}
+	for _, test := range tests {
+		if match := responsesMatch(test.keyType, expected, "www.example.com", test.actual, "a.example.com"); match != test.match {
+			t.Errorf("expected %t for %s cluster and body %q, got %t", test.match, test.keyType, test.actual.Body, match)
+		}
+	}
+}
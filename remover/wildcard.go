@@ -0,0 +1,169 @@
+package remover
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const maxBodySize = 10 * 1024 * 1024
+
+type WildcardBaseline struct {
+	Host          string
+	Status        int
+	ContentLength int
+	Words         int
+	Lines         int
+}
+
+/*
+Checks for every scheme and port on the IP address if the server responds with the same content to random hostnames
+(catch-all virtual host). All hosts whose response matches this wildcard baseline are collapsed into a single duplicates
+entry, using the best match as representative. TLDs among the catch-all hosts are recorded in tlds, like for any other
+duplicates. The remaining entries and the created duplicates are returned.
+*/
+func (p *Remover) detectWildcardHosts(ipaddress string, entries []SimpleHTTPXEntry, tlds map[string]SimpleHTTPXEntry) ([]SimpleHTTPXEntry, map[string]Duplicates) {
+	duplicates := make(map[string]Duplicates)
+	var remaining []SimpleHTTPXEntry
+
+	entriesPerEndpoint := make(map[string][]SimpleHTTPXEntry)
+	for _, entry := range entries {
+		scheme, port := getSchemeAndPort(entry)
+		if scheme == "" {
+			remaining = append(remaining, entry)
+			continue
+		}
+		endpoint := scheme + "://" + net.JoinHostPort(ipaddress, port)
+		entriesPerEndpoint[endpoint] = append(entriesPerEndpoint[endpoint], entry)
+	}
+
+	endpoints := make([]string, 0, len(entriesPerEndpoint))
+	for endpoint := range entriesPerEndpoint {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		endpointEntries := entriesPerEndpoint[endpoint]
+		domain := ExtractDomainAndTldFromString(strings.Split(endpointEntries[0].Input, ":")[0])
+		baseline, err := p.getWildcardBaseline(endpoint, domain)
+		if err != nil {
+			log.Debugf("No wildcard baseline for %s: %s", endpoint, err)
+			remaining = append(remaining, endpointEntries...)
+			continue
+		}
+		var catchAll []SimpleHTTPXEntry
+		for _, entry := range endpointEntries {
+			if baseline.matches(entry) {
+				catchAll = append(catchAll, entry)
+			} else {
+				remaining = append(remaining, entry)
+			}
+		}
+		if len(catchAll) == 0 {
+			continue
+		}
+		log.Infof("Found %d catch-all hosts for %s", len(catchAll), endpoint)
+		representative := catchAll[0]
+		if len(catchAll) > 1 {
+			if bestMatch := getBestDuplicateMatch(catchAll, p.options.Project, tlds); bestMatch.Input != "" {
+				representative = bestMatch
+			}
+		}
+		remaining = append(remaining, representative)
+		duplicate := getDuplicate(representative)
+		duplicate.Reason = "catch-all"
+		duplicate.KeyType = "catch-all"
+		duplicate.Key = endpoint
+		duplicate.Evidence = fmt.Sprintf("random host %s on %s returned status %d with %d words and %d lines",
+			baseline.Host, endpoint, baseline.Status, baseline.Words, baseline.Lines)
+		for _, entry := range catchAll {
+			if entry.Input != representative.Input {
+				duplicate.DuplicateHosts = AppendIfMissing(duplicate.DuplicateHosts, entry.Input)
+			}
+		}
+		if len(duplicate.DuplicateHosts) > 0 {
+			duplicates["catch-all|"+endpoint] = duplicate
+		}
+	}
+	return remaining, duplicates
+}
+
+/*
+Requests the endpoint twice using different random hostnames within the domain. Only if both responses are the same
+the response is used as baseline, otherwise the content is too dynamic to be used for detecting a catch-all host.
+*/
+func (p *Remover) getWildcardBaseline(endpoint string, domain string) (WildcardBaseline, error) {
+	first, err := p.requestWithRandomHost(endpoint, domain)
+	if err != nil {
+		return WildcardBaseline{}, err
+	}
+	second, err := p.requestWithRandomHost(endpoint, domain)
+	if err != nil {
+		return WildcardBaseline{}, err
+	}
+	if first.Status != second.Status || first.Words != second.Words || first.Lines != second.Lines {
+		return WildcardBaseline{}, fmt.Errorf("responses for random hosts differ")
+	}
+	return first, nil
+}
+
+/*
+Requests the endpoint using a random hostname within the domain. The request is sent to the IP address of the endpoint,
+using the random hostname in the URL, so that it is used for the Host header and SNI like for the real hostnames.
+*/
+func (p *Remover) requestWithRandomHost(endpoint string, domain string) (WildcardBaseline, error) {
+	if p.rateLimiter != nil {
+		<-p.rateLimiter.C
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return WildcardBaseline{}, err
+	}
+	randomHost := strings.ToLower(RandStringRunes(16)) + "." + domain
+	response, err := getClientForIPAddress(u.Hostname()).Get(u.Scheme + "://" + net.JoinHostPort(randomHost, u.Port()))
+	if err != nil {
+		return WildcardBaseline{}, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return WildcardBaseline{}, err
+	}
+	words, lines := countWordsAndLines(string(body))
+	return WildcardBaseline{
+		Host:          randomHost,
+		Status:        response.StatusCode,
+		ContentLength: len(body),
+		Words:         words,
+		Lines:         lines,
+	}, nil
+}
+
+func (b WildcardBaseline) matches(entry SimpleHTTPXEntry) bool {
+	return b.Status == entry.Status && b.Words == entry.Words && b.Lines == entry.Lines
+}
+
+// Counts words and lines the same way as HTTPX does.
+func countWordsAndLines(body string) (int, int) {
+	return len(strings.Split(body, " ")), len(strings.Split(body, "\n"))
+}
+
+func getSchemeAndPort(entry SimpleHTTPXEntry) (string, string) {
+	u, err := url.Parse(entry.URL)
+	if err != nil || u.Scheme == "" {
+		return "", ""
+	}
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	return u.Scheme, port
+}
@@ -0,0 +1,78 @@
+package remover
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDetectWildcardHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "www.example.com" {
+			fmt.Fprint(w, "the real page of www")
+			return
+		}
+		fmt.Fprint(w, "catch all page")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	ipaddress, port, _ := net.SplitHostPort(serverURL.Host)
+
+	words, lines := countWordsAndLines("catch all page")
+	newEntry := func(host string, words int) SimpleHTTPXEntry {
+		return SimpleHTTPXEntry{Input: host, Host: ipaddress, URL: "http://" + host + ":" + port, Status: 200, Words: words, Lines: lines}
+	}
+	entries := []SimpleHTTPXEntry{
+		newEntry("www.example.com", 5),
+		newEntry("a.example.com", words),
+		newEntry("example.org", words),
+		newEntry("example.net", words),
+	}
+
+	p := &Remover{options: &Options{Project: "example.com"}}
+	tlds := make(map[string]SimpleHTTPXEntry)
+	remaining, duplicates := p.detectWildcardHosts(ipaddress, entries, tlds)
+
+	if len(duplicates) != 1 {
+		t.Fatalf("expected one catch-all cluster, got %d", len(duplicates))
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.Reason != "catch-all" || len(duplicate.DuplicateHosts) != 2 {
+			t.Errorf("unexpected catch-all cluster %+v", duplicate)
+		}
+	}
+	if len(remaining) != 2 || !containsEntryWithInput(remaining, "www.example.com") {
+		t.Errorf("expected www.example.com and the representative to remain, got %+v", remaining)
+	}
+	if len(tlds) != 1 {
+		t.Errorf("expected the catch-all TLD which isn't the representative to be recorded, got %+v", tlds)
+	}
+}
+
+func TestRandomHostIsUsedForSNI(t *testing.T) {
+	var serverNames []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if r.TLS == nil || r.TLS.ServerName != host {
+			http.Error(w, "unknown server name", http.StatusMisdirectedRequest)
+			return
+		}
+		serverNames = append(serverNames, r.TLS.ServerName)
+		fmt.Fprint(w, "catch all page")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	p := &Remover{options: &Options{Project: "example.com"}}
+	baseline, err := p.getWildcardBaseline("https://"+serverURL.Host, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseline.Status != http.StatusOK || len(serverNames) != 2 || !strings.HasSuffix(serverNames[0], ".example.com") {
+		t.Errorf("expected both requests to use the random hostname for SNI, got %+v and %v", baseline, serverNames)
+	}
+}